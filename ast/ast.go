@@ -1,69 +1,164 @@
 package ast
 
+import (
+	"strings"
+
+	"github.com/jordan-rash/go-wit/token"
+)
+
 type AST struct {
-	Package    PackageNode
-	World      WorldNode
-	Uses       []UseNode
-	Interfaces []InterfaceNode
+	Filename   string   // name of the parsed file, if any
+	Package    *Package // nil when the file has no package declaration
+	Worlds     []*World
+	Uses       []*Use
+	Interfaces []*Interface
+
+	// Packages holds the nested `package ns:name { ... }` blocks of the file
+	Packages []*Package
+}
+
+// World returns the world called name, or nil if there is none.
+func (a *AST) World(name string) *World {
+	return findWorld(a.Worlds, name)
+}
+
+func findWorld(worlds []*World, name string) *World {
+	for _, w := range worlds {
+		if w.Name == name {
+			return w
+		}
+	}
+	return nil
+}
+
+func (a *AST) Validate() bool       { return true }
+func (a *AST) TokenLiteral() string { return "" }
+
+// Pos returns the position of the first item of the file.
+func (a *AST) Pos() (pos token.Position) {
+	a.eachItem(func(n Node) {
+		if p := n.Pos(); pos.Line == 0 || p.Offset < pos.Offset {
+			pos = p
+		}
+	})
+	return pos
+}
+
+// End returns the position immediately after the last item of the file.
+func (a *AST) End() (end token.Position) {
+	a.eachItem(func(n Node) {
+		if e := n.End(); e.Offset > end.Offset {
+			end = e
+		}
+	})
+	return end
 }
 
+func (a *AST) eachItem(f func(Node)) {
+	if a.Package != nil {
+		f(a.Package)
+	}
+	for _, u := range a.Uses {
+		f(u)
+	}
+	for _, i := range a.Interfaces {
+		f(i)
+	}
+	for _, w := range a.Worlds {
+		f(w)
+	}
+	for _, p := range a.Packages {
+		f(p)
+	}
+}
+
+// String returns the tree printed as canonical WIT, as by Fprint.
 func (a *AST) String() string {
-	// ret := a.Package.Namespace + "/" + a.Package.Name
-	// if a.Package.SemVer != "" {
-	// 	ret += "@" + a.Package.SemVer
-	// }
-	// return ret
-	return ""
+	var sb strings.Builder
+	_ = Fprint(&sb, a)
+	return sb.String()
 }
 
 type Node interface {
 	TokenLiteral() string
 	Validate() bool
+	Pos() token.Position // position of first character belonging to the node
+	End() token.Position // position of first character immediately after the node
 }
 
-type InterfaceNode interface {
-	Node
-	interfaceNode()
+// Docs holds the documentation comments (`///` or `/** */`) that precede
+// an item.
+type Docs struct {
+	Comments []token.Token
 }
 
-type WorldNode interface {
-	Node
-	worldNode()
-}
+func (d *Docs) Validate() bool       { return true }
+func (d *Docs) TokenLiteral() string { return d.Comments[0].Literal }
+func (d *Docs) Pos() token.Position  { return d.Comments[0].Pos }
+func (d *Docs) End() token.Position  { return d.Comments[len(d.Comments)-1].End }
 
-type UseNode interface {
-	Node
-	useNode()
-}
+// Text returns the documentation with the comment markers removed, one
+// line of source documentation per line of text.
+func (d *Docs) Text() string {
+	if d == nil {
+		return ""
+	}
 
-type PackageNode interface {
-	Node
-	packageNode()
-}
+	var lines []string
+	for _, c := range d.Comments {
+		if strings.HasPrefix(c.Literal, "///") {
+			lines = append(lines, trimDocLine(strings.TrimPrefix(c.Literal, "///")))
+			continue
+		}
 
-// ------- Delete these
+		body := strings.Split(strings.TrimSuffix(strings.TrimPrefix(c.Literal, "/**"), "*/"), "\n")
+		for i, l := range body {
+			l = strings.TrimSpace(l)
+			if i > 0 {
+				l = strings.TrimPrefix(l, "*")
+			}
+			body[i] = trimDocLine(l)
+		}
+		for len(body) > 0 && body[0] == "" {
+			body = body[1:]
+		}
+		for len(body) > 0 && body[len(body)-1] == "" {
+			body = body[:len(body)-1]
+		}
+		lines = append(lines, body...)
+	}
 
-type Expression interface {
-	Node
-	expressionNode()
+	return strings.Join(lines, "\n")
 }
 
-// --------
+func trimDocLine(l string) string {
+	return strings.TrimRight(strings.TrimPrefix(l, " "), " \t\r")
+}
 
 type Package struct {
 	Identifier *Identifier
+	Docs       *Docs
 
 	Namespace string
 	Name      string
-	SemVer    string
+	Version   *Version // nil when the package is unversioned
+
+	// items of a nested package block, empty for a package declaration
+	Interfaces []*Interface
+	Worlds     []*World
+
+	Span token.Span
 }
 
-func (p *Package) packageNode()         {}
 func (p *Package) Validate() bool       { return true }
 func (p *Package) TokenLiteral() string { return p.Identifier.Token.Literal }
+func (p *Package) Pos() token.Position  { return p.Span.Start }
+func (p *Package) End() token.Position  { return p.Span.End }
 
 type World struct {
 	Identifier *Identifier
+	Docs       *Docs
+	Gates      Gates
 
 	Name string
 
@@ -72,43 +167,141 @@ type World struct {
 	UseItems     []*UseShape
 	TypedefItems []*TypeDef
 	IncludeItems []*IncludeShape
+	Span         token.Span
 }
 
-func (w *World) worldNode()           {}
 func (w *World) Validate() bool       { return true }
 func (w *World) TokenLiteral() string { return w.Identifier.Token.Literal }
+func (w *World) Pos() token.Position  { return w.Span.Start }
+func (w *World) End() token.Position  { return w.Span.End }
+
+// Items returns the items of the world in source order. Items without a
+// position, such as items created by hand, follow the item before them in
+// the list holding them.
+func (w *World) Items() []WorldItem {
+	return inSourceOrder(
+		worldItems(w.ImportItems),
+		worldItems(w.ExportItems),
+		worldItems(w.UseItems),
+		worldItems(w.TypedefItems),
+		worldItems(w.IncludeItems),
+	)
+}
+
+func worldItems[T WorldItem](items []T) []WorldItem {
+	ret := make([]WorldItem, len(items))
+	for i, item := range items {
+		ret[i] = item
+	}
+	return ret
+}
+
+// inSourceOrder merges lists of nodes, each in source order, into one list
+// in source order.
+func inSourceOrder[N Node](lists ...[]N) []N {
+	var ret []N
+	for {
+		next := -1
+		for i, l := range lists {
+			if len(l) == 0 {
+				continue
+			}
+			if !l[0].Pos().IsValid() {
+				next = i
+				break
+			}
+			if next < 0 || l[0].Pos().Offset < lists[next][0].Pos().Offset {
+				next = i
+			}
+		}
+		if next < 0 {
+			return ret
+		}
+
+		ret = append(ret, lists[next][0])
+		lists[next] = lists[next][1:]
+	}
+}
 
 type Interface struct {
 	Identifier *Identifier
+	Docs       *Docs
+	Gates      Gates
 
 	Name string
 
 	Items InterfaceItems
+	Span  token.Span
 }
 
-func (i *Interface) interfaceNode()       {}
 func (i *Interface) Validate() bool       { return true }
 func (i *Interface) TokenLiteral() string { return i.Identifier.Token.Literal }
+func (i *Interface) Pos() token.Position  { return i.Span.Start }
+func (i *Interface) End() token.Position  { return i.Span.End }
 
 type InterfaceItems struct {
 	UseItems     []*UseShape
 	TypedefItems []*TypeDef
 	FuncItems    []*FuncShape
+	Span         token.Span
 }
 
-func (i *InterfaceItems) expressionNode()      {}
 func (i *InterfaceItems) Validate() bool       { return true }
 func (i *InterfaceItems) TokenLiteral() string { return "" }
+func (i *InterfaceItems) Pos() token.Position  { return i.Span.Start }
+func (i *InterfaceItems) End() token.Position  { return i.Span.End }
 
+// Use is a top level use statement. Identifier names the used interface and
+// carries the alias given with `as`, if any.
 type Use struct {
 	Identifier *Identifier
+	Docs       *Docs
+	Gates      Gates
 
-	UseInterface struct {
-		Interface Interface
-		Items     []Identifier
-	}
+	UseInterface UseInterface
+	Span         token.Span
 }
 
-func (u *Use) useNode()             {}
 func (u *Use) Validate() bool       { return true }
 func (u *Use) TokenLiteral() string { return u.Identifier.Token.Literal }
+func (u *Use) Pos() token.Position  { return u.Span.Start }
+func (u *Use) End() token.Position  { return u.Span.End }
+
+// UseInterface is the interface a use statement refers to together with the
+// names it brings into scope. Aliases given with `as` are stored on the
+// identifiers in Items.
+type UseInterface struct {
+	Path  *UsePath
+	Items []Identifier
+}
+
+// UsePath refers to an interface either by a name local to the package or
+// fully qualified as namespace:package/name@version.
+type UsePath struct {
+	Namespace *Identifier
+	Package   *Identifier
+	Name      *Identifier
+	Version   *Version
+
+	Span token.Span
+}
+
+func (u *UsePath) Validate() bool       { return true }
+func (u *UsePath) TokenLiteral() string { return u.String() }
+func (u *UsePath) Pos() token.Position  { return u.Span.Start }
+func (u *UsePath) End() token.Position  { return u.Span.End }
+
+// IsQualified reports whether the path names a package.
+func (u *UsePath) IsQualified() bool { return u.Package != nil }
+
+func (u *UsePath) String() string {
+	if !u.IsQualified() {
+		return u.Name.Value
+	}
+
+	ret := u.Namespace.Value + ":" + u.Package.Value + "/" + u.Name.Value
+	if u.Version != nil {
+		ret += "@" + u.Version.String()
+	}
+	return ret
+}
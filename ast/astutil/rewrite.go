@@ -0,0 +1,445 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file is adapted from golang.org/x/tools/go/ast/astutil/rewrite.go
+// to walk WIT syntax trees.
+
+// Package astutil contains utilities for rewriting WIT syntax trees.
+package astutil
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jordan-rash/go-wit/ast"
+)
+
+// An ApplyFunc is invoked by Apply for each node n, even if n is nil,
+// before and/or after the node's children, using a Cursor describing
+// the current node and providing operations on it.
+//
+// The return value of ApplyFunc controls the syntax tree traversal.
+// See Apply for details.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses a syntax tree recursively, starting with root,
+// and calling pre and post for each node as described below.
+// Apply returns the syntax tree, possibly modified.
+//
+// If pre is not nil, it is called for each node before the node's
+// children are traversed (pre-order). If pre returns false, no
+// children are traversed, and post is not called for that node.
+//
+// If post is not nil, and a prior call of pre didn't return false,
+// post is called for each node after its children are traversed
+// (post-order). If post returns false, traversal is terminated and
+// Apply returns immediately.
+//
+// Only fields that refer to AST nodes are considered children, in the
+// same order and with the same exceptions as ast.Walk. Nodes keep their
+// docs, gates and positions when they are moved or reinserted; nodes
+// created by hand have no positions.
+func Apply(root ast.Node, pre, post ApplyFunc) (result ast.Node) {
+	parent := &struct{ ast.Node }{root}
+	defer func() {
+		if r := recover(); r != nil && r != abort {
+			panic(r)
+		}
+		result = parent.Node
+	}()
+	a := &application{pre: pre, post: post}
+	a.apply(parent, "Node", nil, root)
+	return
+}
+
+var abort = new(int) // singleton, to signal termination of Apply
+
+// A Cursor describes a node encountered during Apply.
+// Information about the node and its parent is available
+// from the Node, Parent, Name, and Index methods.
+//
+// If p is a variable of type and value of the current parent node
+// c.Parent(), and f is the field identifier with name c.Name(),
+// the following invariants hold:
+//
+//	p.f            == c.Node()  if c.Index() <  0
+//	p.f[c.Index()] == c.Node()  if c.Index() >= 0
+//
+// The methods Replace, Delete, InsertBefore, and InsertAfter
+// can be used to change the AST without disrupting Apply.
+type Cursor struct {
+	parent ast.Node
+	name   string
+	iter   *iterator // valid if non-nil
+	node   ast.Node
+}
+
+// Node returns the current Node.
+func (c *Cursor) Node() ast.Node { return c.node }
+
+// Parent returns the parent of the current Node.
+func (c *Cursor) Parent() ast.Node { return c.parent }
+
+// Name returns the name of the parent Node field that contains the current
+// Node. If the parent is a *ast.ParamList or *ast.ResultList the name is
+// empty, fields of embedded structs are named by their path such as
+// "UseInterface.Items".
+func (c *Cursor) Name() string { return c.name }
+
+// Index reports the index >= 0 of the current Node in the slice of Nodes
+// that contains it, or a value < 0 if the current Node is not part of a
+// slice. The index of the current node changes if InsertBefore is called
+// while processing the current node.
+func (c *Cursor) Index() int {
+	if c.iter != nil {
+		return c.iter.index
+	}
+	return -1
+}
+
+// field returns the current node's parent field value.
+func (c *Cursor) field() reflect.Value {
+	v := reflect.Indirect(reflect.ValueOf(c.parent))
+	if c.name == "" {
+		return v
+	}
+	for _, name := range strings.Split(c.name, ".") {
+		v = v.FieldByName(name)
+	}
+	return v
+}
+
+// Replace replaces the current Node with n.
+// The replacement node is not walked by Apply.
+func (c *Cursor) Replace(n ast.Node) {
+	v := c.field()
+	if i := c.Index(); i >= 0 {
+		v = v.Index(i)
+	}
+	v.Set(nodeValue(v.Type(), n))
+	c.node = n
+}
+
+// Delete deletes the current Node from its containing slice.
+// If the current Node is not part of a slice, Delete panics.
+func (c *Cursor) Delete() {
+	i := c.Index()
+	if i < 0 {
+		panic("Delete node not contained in slice")
+	}
+	v := c.field()
+	l := v.Len()
+	reflect.Copy(v.Slice(i, l), v.Slice(i+1, l))
+	v.Index(l - 1).Set(reflect.Zero(v.Type().Elem()))
+	v.SetLen(l - 1)
+	c.iter.step--
+}
+
+// InsertAfter inserts n after the current Node in its containing slice.
+// If the current Node is not part of a slice, InsertAfter panics.
+// Apply does not walk n.
+func (c *Cursor) InsertAfter(n ast.Node) {
+	i := c.Index()
+	if i < 0 {
+		panic("InsertAfter node not contained in slice")
+	}
+	v := c.field()
+	v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	l := v.Len()
+	reflect.Copy(v.Slice(i+2, l), v.Slice(i+1, l))
+	v.Index(i + 1).Set(nodeValue(v.Type().Elem(), n))
+	c.iter.step++
+}
+
+// InsertBefore inserts n before the current Node in its containing slice.
+// If the current Node is not part of a slice, InsertBefore panics.
+// Apply will not walk n.
+func (c *Cursor) InsertBefore(n ast.Node) {
+	i := c.Index()
+	if i < 0 {
+		panic("InsertBefore node not contained in slice")
+	}
+	v := c.field()
+	v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	l := v.Len()
+	reflect.Copy(v.Slice(i+1, l), v.Slice(i, l))
+	v.Index(i).Set(nodeValue(v.Type().Elem(), n))
+	c.iter.index++
+}
+
+// nodeValue returns n as a value assignable to a field of type t. Fields
+// holding a node by value, such as ast.Interface.Items, get a copy of the
+// node n points to.
+func nodeValue(t reflect.Type, n ast.Node) reflect.Value {
+	if n == nil {
+		return reflect.Zero(t)
+	}
+	v := reflect.ValueOf(n)
+	if t.Kind() == reflect.Struct {
+		return v.Elem()
+	}
+	return v
+}
+
+// application carries all the shared data so we can pass it around cheaply.
+type application struct {
+	pre, post ApplyFunc
+	cursor    Cursor
+	iter      iterator
+}
+
+func (a *application) apply(parent ast.Node, name string, iter *iterator, n ast.Node) {
+	// convert typed nil into untyped nil
+	if v := reflect.ValueOf(n); v.Kind() == reflect.Pointer && v.IsNil() {
+		n = nil
+	}
+
+	// avoid heap-allocating a new cursor for each apply call; reuse a.cursor instead
+	saved := a.cursor
+	a.cursor.parent = parent
+	a.cursor.name = name
+	a.cursor.iter = iter
+	a.cursor.node = n
+
+	if a.pre != nil && !a.pre(&a.cursor) {
+		a.cursor = saved
+		return
+	}
+
+	// walk children
+	// (the order of the cases and fields matches ast.Walk)
+	switch n := n.(type) {
+	case nil:
+		// nothing to do
+
+	case *ast.AST:
+		a.apply(n, "Package", nil, n.Package)
+		a.applyList(n, "Uses")
+		a.applyList(n, "Interfaces")
+		a.applyList(n, "Worlds")
+		a.applyList(n, "Packages")
+
+	// Items
+	case *ast.Docs, *ast.Identifier:
+		// nothing to do
+
+	case *ast.Package:
+		a.apply(n, "Docs", nil, n.Docs)
+		a.applyList(n, "Interfaces")
+		a.applyList(n, "Worlds")
+
+	case *ast.World:
+		a.applyDocs(n, n.Docs)
+		a.applyList(n, "ExportItems")
+		a.applyList(n, "ImportItems")
+		a.applyList(n, "UseItems")
+		a.applyList(n, "TypedefItems")
+		a.applyList(n, "IncludeItems")
+
+	case *ast.Interface:
+		a.applyDocs(n, n.Docs)
+		a.apply(n, "Items", nil, &n.Items)
+
+	case *ast.InterfaceItems:
+		a.applyList(n, "UseItems")
+		a.applyList(n, "TypedefItems")
+		a.applyList(n, "FuncItems")
+
+	case *ast.Use:
+		a.applyDocs(n, n.Docs)
+		a.apply(n, "UseInterface.Path", nil, n.UseInterface.Path)
+		a.applyList(n, "UseInterface.Items")
+
+	case *ast.UsePath:
+		a.apply(n, "Namespace", nil, n.Namespace)
+		a.apply(n, "Package", nil, n.Package)
+		a.apply(n, "Name", nil, n.Name)
+
+	case *ast.Gate:
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Feature", nil, n.Feature)
+
+	// Types
+	case *ast.Primitive:
+		// nothing to do
+
+	case *ast.Named:
+		a.apply(n, "Name", nil, n.Name)
+
+	case *ast.List:
+		a.apply(n, "Elem", nil, n.Elem)
+
+	case *ast.Option:
+		a.apply(n, "Elem", nil, n.Elem)
+
+	case *ast.Result:
+		a.apply(n, "Ok", nil, n.Ok)
+		a.apply(n, "Err", nil, n.Err)
+
+	case *ast.Tuple:
+		a.applyList(n, "Elems")
+
+	case *ast.Handle:
+		a.apply(n, "Resource", nil, n.Resource)
+
+	case *ast.Future:
+		a.apply(n, "Elem", nil, n.Elem)
+
+	case *ast.Stream:
+		a.apply(n, "Elem", nil, n.Elem)
+
+	// Type definitions
+	case *ast.TypeDef:
+		a.applyDocs(n, n.Docs)
+		a.apply(n, "Kind", nil, n.Kind)
+
+	case *ast.TypeShape:
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Value", nil, n.Value)
+
+	case *ast.RecordShape:
+		a.apply(n, "Identifier", nil, n.Identifier)
+		a.applyList(n, "Fields")
+
+	case *ast.RecordField:
+		a.applyDocs(n, n.Docs)
+		a.apply(n, "Identifier", nil, n.Identifier)
+		a.apply(n, "Type", nil, n.Type)
+
+	case *ast.VariantShape:
+		a.apply(n, "Identifier", nil, n.Identifier)
+		a.applyList(n, "Cases")
+
+	case *ast.VariantCase:
+		a.applyDocs(n, n.Docs)
+		a.apply(n, "Identifier", nil, n.Identifier)
+		a.apply(n, "Type", nil, n.Type)
+
+	case *ast.EnumShape:
+		a.apply(n, "Name", nil, n.Name)
+		a.applyList(n, "Cases")
+
+	case *ast.EnumCase:
+		a.applyDocs(n, n.Docs)
+		a.apply(n, "Identifier", nil, n.Identifier)
+
+	case *ast.FlagShape:
+		a.apply(n, "Name", nil, n.Name)
+		a.applyList(n, "Flags")
+
+	case *ast.Flag:
+		a.applyDocs(n, n.Docs)
+		a.apply(n, "Identifier", nil, n.Identifier)
+
+	case *ast.UnionShape:
+		a.apply(n, "Name", nil, n.Name)
+		a.applyList(n, "Cases")
+
+	case *ast.ResourceShape:
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Constructor", nil, n.Constructor)
+		a.applyList(n, "Methods")
+		a.applyList(n, "StaticFuncs")
+
+	// Functions
+	case *ast.FuncShape:
+		a.applyDocs(n, n.Docs)
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Func", nil, n.Func)
+
+	case *ast.FuncType:
+		a.apply(n, "ParamList", nil, n.ParamList)
+		a.apply(n, "ResultList", nil, n.ResultList)
+
+	case *ast.ParamList, *ast.ResultList:
+		a.applyList(n, "")
+
+	case *ast.NamedType:
+		a.apply(n, "Name", nil, n.Name)
+		a.apply(n, "Type", nil, n.Type)
+
+	// World items
+	case *ast.UseShape:
+		a.applyDocs(n, n.Docs)
+		a.apply(n, "UseInterface.Path", nil, n.UseInterface.Path)
+		a.applyList(n, "UseInterface.Items")
+
+	case *ast.ExportShape:
+		a.applyDocs(n, n.Docs)
+		if n.Path != nil {
+			a.apply(n, "Path", nil, n.Path)
+		} else {
+			a.apply(n, "Name", nil, n.Name)
+		}
+		a.apply(n, "Func", nil, n.Func)
+		a.apply(n, "Interface", nil, n.Interface)
+
+	case *ast.ImportShape:
+		a.applyDocs(n, n.Docs)
+		if n.Path != nil {
+			a.apply(n, "Path", nil, n.Path)
+		} else {
+			a.apply(n, "Name", nil, n.Name)
+		}
+		a.apply(n, "Func", nil, n.Func)
+		a.apply(n, "Interface", nil, n.Interface)
+
+	case *ast.IncludeShape:
+		a.applyDocs(n, n.Docs)
+		if n.Path != nil {
+			a.apply(n, "Path", nil, n.Path)
+		} else {
+			a.apply(n, "Name", nil, n.Name)
+		}
+		a.applyList(n, "With")
+
+	default:
+		panic(fmt.Sprintf("Apply: unexpected node type %T", n))
+	}
+
+	if a.post != nil && !a.post(&a.cursor) {
+		panic(abort)
+	}
+
+	a.cursor = saved
+}
+
+// applyDocs applies to the Docs and Gates of an item.
+func (a *application) applyDocs(parent ast.Node, docs *ast.Docs) {
+	a.apply(parent, "Docs", nil, docs)
+	a.applyList(parent, "Gates")
+}
+
+// An iterator controls iteration over a slice of nodes.
+type iterator struct {
+	index, step int
+}
+
+func (a *application) applyList(parent ast.Node, name string) {
+	// avoid heap-allocating a new iterator for each applyList call; reuse a.iter instead
+	saved := a.iter
+	a.iter.index = 0
+	for {
+		// must reload parent.name each time, since cursor modifications might change it
+		c := Cursor{parent: parent, name: name}
+		v := c.field()
+		if a.iter.index >= v.Len() {
+			break
+		}
+
+		// element x may be nil in a bad AST - be cautious
+		var x ast.Node
+		if e := v.Index(a.iter.index); e.Kind() == reflect.Struct {
+			x = e.Addr().Interface().(ast.Node)
+		} else if e.IsValid() && !e.IsNil() {
+			x = e.Interface().(ast.Node)
+		}
+
+		a.iter.step = 1
+		a.apply(parent, name, &a.iter, x)
+		a.iter.index += a.iter.step
+	}
+	a.iter = saved
+}
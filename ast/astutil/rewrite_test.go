@@ -0,0 +1,237 @@
+package astutil_test
+
+import (
+	"testing"
+
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/ast/astutil"
+	"github.com/jordan-rash/go-wit/parser"
+	"github.com/jordan-rash/go-wit/token"
+	"github.com/stretchr/testify/assert"
+)
+
+const input = `package local:demo;
+
+interface types {
+  record pair {
+    left: u32,
+    right: list<pair-id>,
+  }
+
+  type pair-id = u64
+
+  /// Old way to make a pair
+  @deprecated(version = 0.2.0)
+  make: func() -> pair
+  /// Swaps the pair
+  swap: func(p: pair) -> pair
+}
+
+world demo {
+  use types.{pair}
+  import old: func()
+  export run: func(p: pair)
+}`
+
+func parse(t *testing.T) *ast.AST {
+	t.Helper()
+
+	tree, err := parser.ParseString("demo.wit", input)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return tree
+}
+
+func names(funcs []*ast.FuncShape) []string {
+	var ret []string
+	for _, f := range funcs {
+		ret = append(ret, f.Name.Value)
+	}
+	return ret
+}
+
+func TestApplyReplace(t *testing.T) {
+	tree := parse(t)
+
+	renamed := 0
+	astutil.Apply(tree, func(c *astutil.Cursor) bool {
+		if id, ok := c.Node().(*ast.Identifier); ok && id.Value == "pair" {
+			c.Replace(&ast.Identifier{Token: id.Token, Alias: id.Alias, Value: "couple"})
+			renamed++
+		}
+		return true
+	}, nil)
+
+	// the record, its three uses in functions, the world's use and export
+	assert.Equal(t, 6, renamed)
+
+	types := tree.Interfaces[0]
+	assert.Equal(t, "couple", types.Items.TypedefItems[0].Kind.(*ast.RecordShape).Identifier.Value)
+	assert.Equal(t, "couple", (*types.Items.FuncItems[1].Func.ParamList)[0].Type.(*ast.Named).Name.Value)
+
+	w := tree.World("demo")
+	assert.Equal(t, "couple", w.UseItems[0].UseInterface.Items[0].Value)
+
+	// positions and docs are kept
+	swap := types.Items.FuncItems[1]
+	assert.Equal(t, "Swaps the pair", swap.Docs.Text())
+	assert.Equal(t, "demo.wit:15:17", (*swap.Func.ParamList)[0].Type.Pos().String())
+}
+
+func TestApplyReplaceType(t *testing.T) {
+	tree := parse(t)
+
+	// u64 -> option<u64>, the replacement is not walked again
+	astutil.Apply(tree, func(c *astutil.Cursor) bool {
+		if p, ok := c.Node().(*ast.Primitive); ok && p.Token.Type == token.KEYWORD_U64 {
+			c.Replace(&ast.Option{Token: token.Token{Type: token.KEYWORD_OPTION, Literal: "option"}, Elem: p})
+		}
+		return true
+	}, nil)
+
+	ts := tree.Interfaces[0].Items.TypedefItems[1].Kind.(*ast.TypeShape)
+	if opt, ok := ts.Value.(*ast.Option); assert.True(t, ok) {
+		assert.Equal(t, "u64", opt.Elem.TokenLiteral())
+	}
+}
+
+func TestApplyDelete(t *testing.T) {
+	tree := parse(t)
+
+	var visited []string
+	astutil.Apply(tree, func(c *astutil.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.FuncShape:
+			visited = append(visited, n.Name.Value)
+			if n.Gates.Deprecated() != nil {
+				c.Delete()
+				return false
+			}
+		case *ast.ImportShape:
+			c.Delete()
+		}
+		return true
+	}, nil)
+
+	assert.Equal(t, []string{"make", "swap"}, visited)
+	assert.Equal(t, []string{"swap"}, names(tree.Interfaces[0].Items.FuncItems))
+
+	w := tree.World("demo")
+	assert.Empty(t, w.ImportItems)
+	assert.Len(t, w.ExportItems, 1)
+}
+
+func TestApplyInsert(t *testing.T) {
+	tree := parse(t)
+
+	newFunc := func(name string) *ast.FuncShape {
+		return &ast.FuncShape{
+			Name: &ast.Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: name}, Value: name},
+			Func: &ast.FuncType{ParamList: &ast.ParamList{}},
+		}
+	}
+
+	var visited []string
+	astutil.Apply(tree, func(c *astutil.Cursor) bool {
+		f, ok := c.Node().(*ast.FuncShape)
+		if !ok {
+			return true
+		}
+
+		visited = append(visited, f.Name.Value)
+		switch f.Name.Value {
+		case "make":
+			c.InsertBefore(newFunc("new"))
+			assert.Equal(t, 1, c.Index())
+		case "swap":
+			c.InsertAfter(newFunc("flip"))
+		}
+		return true
+	}, nil)
+
+	// inserted nodes are not walked
+	assert.Equal(t, []string{"make", "swap"}, visited)
+
+	funcs := tree.Interfaces[0].Items.FuncItems
+	assert.Equal(t, []string{"new", "make", "swap", "flip"}, names(funcs))
+	assert.Equal(t, "Old way to make a pair", funcs[1].Docs.Text())
+	assert.Equal(t, "Swaps the pair", funcs[2].Docs.Text())
+
+	// insert a world export after the existing one and a gate before the
+	// deprecation of make
+	since := &ast.Gate{Name: &ast.Identifier{Value: ast.GATE_SINCE}, Version: &ast.Version{Minor: 1}}
+	astutil.Apply(tree, func(c *astutil.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.ExportShape:
+			c.InsertAfter(&ast.ExportShape{Name: &ast.Identifier{Value: "stop"}, Func: n.Func})
+		case *ast.Gate:
+			c.InsertBefore(since)
+		}
+		return true
+	}, nil)
+
+	w := tree.World("demo")
+	if assert.Len(t, w.ExportItems, 2) {
+		assert.Equal(t, "stop", w.ExportItems[1].Name.Value)
+	}
+	if assert.Len(t, funcs[1].Gates, 2) {
+		assert.Same(t, since, funcs[1].Gates[0])
+		assert.Equal(t, ast.GATE_DEPRECATED, funcs[1].Gates[1].Name.Value)
+	}
+	assert.Equal(t, "0.1.0", funcs[1].Gates.Since().String())
+}
+
+func TestApplyCursor(t *testing.T) {
+	tree := parse(t)
+
+	astutil.Apply(tree, func(c *astutil.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.Interface:
+			assert.Equal(t, tree, c.Parent())
+			assert.Equal(t, "Interfaces", c.Name())
+			assert.Equal(t, 0, c.Index())
+		case *ast.InterfaceItems:
+			assert.Equal(t, "Items", c.Name())
+			assert.Equal(t, -1, c.Index())
+		case *ast.NamedType:
+			assert.Equal(t, "", c.Name())
+		case *ast.Identifier:
+			if _, ok := c.Parent().(*ast.UseShape); ok {
+				assert.Equal(t, "UseInterface.Items", c.Name())
+				assert.Equal(t, "pair", n.Value)
+			}
+		}
+		return true
+	}, nil)
+
+	// the root itself can be replaced
+	other := &ast.AST{Filename: "other.wit"}
+	root := astutil.Apply(tree, func(c *astutil.Cursor) bool {
+		if _, ok := c.Node().(*ast.AST); ok {
+			c.Replace(other)
+		}
+		return false
+	}, nil)
+	assert.Equal(t, other, root)
+
+	// returning false from post stops the traversal
+	count := 0
+	astutil.Apply(tree, nil, func(c *astutil.Cursor) bool {
+		if _, ok := c.Node().(*ast.FuncShape); ok {
+			count++
+			return false
+		}
+		return true
+	})
+	assert.Equal(t, 1, count)
+
+	assert.Panics(t, func() {
+		astutil.Apply(tree, func(c *astutil.Cursor) bool {
+			if _, ok := c.Node().(*ast.InterfaceItems); ok {
+				c.Delete()
+			}
+			return true
+		}, nil)
+	})
+}
@@ -0,0 +1,66 @@
+package ast
+
+import "github.com/jordan-rash/go-wit/token"
+
+// Names of the feature gate attributes
+const (
+	GATE_SINCE      = "since"
+	GATE_UNSTABLE   = "unstable"
+	GATE_DEPRECATED = "deprecated"
+)
+
+// Gate is a feature gate attribute preceding an item, one of
+// `@since(version = 0.2.1)`, `@unstable(feature = foo)` or
+// `@deprecated(version = 0.2.2)`.
+type Gate struct {
+	Token   token.Token // the `@`
+	Name    *Identifier
+	Version *Version    // nil when the gate has no version argument
+	Feature *Identifier // nil when the gate has no feature argument
+	Span    token.Span
+}
+
+func (g *Gate) Validate() bool       { return g.Name != nil }
+func (g *Gate) TokenLiteral() string { return g.Token.Literal }
+func (g *Gate) Pos() token.Position  { return g.Span.Start }
+func (g *Gate) End() token.Position  { return g.Span.End }
+
+// Gates are the feature gates of an item, in source order.
+type Gates []*Gate
+
+// Feature returns the feature an item is gated behind, or "" if the item
+// is stable.
+func (g Gates) Feature() string {
+	for _, gate := range g {
+		if gate.Name.Value == GATE_UNSTABLE && gate.Feature != nil {
+			return gate.Feature.Value
+		}
+	}
+	return ""
+}
+
+// Enabled reports whether an item with these gates is available when the
+// given features are enabled. Stable items are always available.
+func (g Gates) Enabled(features map[string]bool) bool {
+	f := g.Feature()
+	return f == "" || features[f]
+}
+
+// Since returns the version an item was introduced in, or nil.
+func (g Gates) Since() *Version {
+	return g.version(GATE_SINCE)
+}
+
+// Deprecated returns the version an item was deprecated in, or nil.
+func (g Gates) Deprecated() *Version {
+	return g.version(GATE_DEPRECATED)
+}
+
+func (g Gates) version(name string) *Version {
+	for _, gate := range g {
+		if gate.Name.Value == name {
+			return gate.Version
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package ast
+
+// PackageSet is a WIT package assembled from one or more files, typically
+// the .wit files of a directory, that share one package declaration.
+type PackageSet struct {
+	Package *Package // declaration shared by the files, nil if none has one
+	Files   []*AST
+
+	Uses       []*Use
+	Interfaces []*Interface
+	Worlds     []*World
+
+	// Packages holds the nested package blocks of all files, those with the
+	// same name merged into one
+	Packages []*Package
+}
+
+// Interface returns the interface called name, or nil if there is none.
+func (s *PackageSet) Interface(name string) *Interface {
+	for _, i := range s.Interfaces {
+		if i.Name == name {
+			return i
+		}
+	}
+	return nil
+}
+
+// World returns the world called name, or nil if there is none.
+func (s *PackageSet) World(name string) *World {
+	return findWorld(s.Worlds, name)
+}
@@ -0,0 +1,596 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jordan-rash/go-wit/token"
+)
+
+// Fprint prints node as canonical WIT to w. Node may be a whole file, an
+// item such as an interface, world or type definition, or any node nested
+// in one, such as a type or a function signature.
+//
+// Items are indented by two spaces, members of records, variants, enums,
+// flags and unions are printed one per line with a trailing comma, and
+// documentation comments and feature gates are printed before the item
+// they belong to. Items that don't end in a block end in `;`, as the
+// current WIT syntax requires. Printing a parsed file and parsing the output
+// again gives the same tree, apart from positions.
+func Fprint(w io.Writer, node Node) error {
+	p := new(printer)
+	if err := p.node(node); err != nil {
+		return err
+	}
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+const indentation = "  "
+
+type printer struct {
+	buf    bytes.Buffer
+	indent int
+}
+
+// line writes s on a line of its own at the current indentation.
+func (p *printer) line(s string) {
+	p.buf.WriteString(strings.Repeat(indentation, p.indent))
+	p.buf.WriteString(s)
+	p.buf.WriteByte('\n')
+}
+
+func (p *printer) node(node Node) error {
+	switch n := node.(type) {
+	case *AST:
+		p.file(n)
+
+	case *Package:
+		if len(n.Interfaces) == 0 && len(n.Worlds) == 0 {
+			p.packageDecl(n)
+		} else {
+			p.item(n)
+		}
+
+	case *Interface, *World, *Use, *UseShape, *TypeDef,
+		*FuncShape, *ImportShape, *ExportShape, *IncludeShape:
+		p.item(n)
+
+	case *InterfaceItems:
+		p.items(interfaceItems(n))
+
+	case *Docs:
+		p.leading(n, nil)
+
+	case *Gate:
+		p.line(gate(n))
+
+	case TypeDefKind:
+		p.typeDefKind(n)
+
+	case *RecordField:
+		p.leading(n.Docs, n.Gates)
+		p.line(field(n))
+
+	case *VariantCase:
+		p.leading(n.Docs, n.Gates)
+		p.line(variantCase(n))
+
+	case *EnumCase:
+		p.leading(n.Docs, n.Gates)
+		p.line(ident(n.Identifier))
+
+	case *Flag:
+		p.leading(n.Docs, n.Gates)
+		p.line(ident(n.Identifier))
+
+	case Type:
+		p.buf.WriteString(typ(n))
+
+	case *FuncType:
+		p.buf.WriteString(funcType(n))
+
+	case *ParamList:
+		p.buf.WriteString(params(n))
+
+	case *ResultList:
+		p.buf.WriteString(strings.TrimPrefix(results(n), " "))
+
+	case *NamedType:
+		p.buf.WriteString(namedType(n))
+
+	case *Identifier:
+		p.buf.WriteString(ident(n))
+
+	case *UsePath:
+		p.buf.WriteString(usePath(n))
+
+	default:
+		return fmt.Errorf("ast.Fprint: unsupported node type %T", node)
+	}
+	return nil
+}
+
+// file prints the package declaration followed by the top level items.
+func (p *printer) file(a *AST) {
+	items := inSourceOrder(nodes(a.Uses), nodes(a.Interfaces), nodes(a.Worlds), nodes(a.Packages))
+	if a.Package != nil {
+		p.packageDecl(a.Package)
+		if len(items) > 0 {
+			p.buf.WriteByte('\n')
+		}
+	}
+	p.items(items)
+}
+
+// items prints the items of a file or body. Items are separated by a blank
+// line, except for runs of one line items of the same kind without
+// documentation.
+func (p *printer) items(items []Node) {
+	for i, n := range items {
+		if i > 0 && separate(items[i-1], n) {
+			p.buf.WriteByte('\n')
+		}
+		p.item(n)
+	}
+}
+
+func separate(prev, next Node) bool {
+	docs, gates := leading(next)
+	g := group(next)
+	return g == "" || g != group(prev) || docs != nil || len(gates) > 0
+}
+
+// group returns the kind of one line items grouped without blank lines
+// between them, or "" for items that are always set apart.
+func group(n Node) string {
+	switch n := n.(type) {
+	case *Use, *UseShape:
+		return "use"
+	case *FuncShape, constructor:
+		return "func"
+	case *ImportShape:
+		if n.Interface == nil {
+			return "import"
+		}
+	case *ExportShape:
+		if n.Interface == nil {
+			return "export"
+		}
+	case *IncludeShape:
+		return "include"
+	case *TypeDef:
+		switch k := n.Kind.(type) {
+		case *TypeShape:
+			return "type"
+		case *ResourceShape:
+			if len(resourceFuncs(k)) == 0 {
+				return "type"
+			}
+		}
+	}
+	return ""
+}
+
+// leading returns the documentation and gates of an item.
+func leading(n Node) (*Docs, Gates) {
+	switch n := n.(type) {
+	case *Package:
+		return n.Docs, nil
+	case *Interface:
+		return n.Docs, n.Gates
+	case *World:
+		return n.Docs, n.Gates
+	case *Use:
+		return n.Docs, n.Gates
+	case *UseShape:
+		return n.Docs, n.Gates
+	case *TypeDef:
+		return n.Docs, n.Gates
+	case *FuncShape:
+		return n.Docs, n.Gates
+	case constructor:
+		return n.Docs, n.Gates
+	case *ImportShape:
+		return n.Docs, n.Gates
+	case *ExportShape:
+		return n.Docs, n.Gates
+	case *IncludeShape:
+		return n.Docs, n.Gates
+	}
+	return nil, nil
+}
+
+// block prints header followed by the items in braces, or by `{}` when
+// there are none.
+func (p *printer) block(header string, items []Node) {
+	if len(items) == 0 {
+		p.line(header + " {}")
+		return
+	}
+
+	p.line(header + " {")
+	p.indent++
+	p.items(items)
+	p.indent--
+	p.line("}")
+}
+
+// members prints header followed by the lines printed by member for each
+// of the n members in braces.
+func (p *printer) members(header string, n int, member func(i int)) {
+	if n == 0 {
+		p.line(header + " {}")
+		return
+	}
+
+	p.line(header + " {")
+	p.indent++
+	for i := 0; i < n; i++ {
+		member(i)
+	}
+	p.indent--
+	p.line("}")
+}
+
+// leading prints documentation comments as written, followed by the gates,
+// each on a line of its own.
+func (p *printer) leading(docs *Docs, gates Gates) {
+	if docs != nil {
+		for _, c := range docs.Comments {
+			p.line(c.Literal)
+		}
+	}
+	for _, g := range gates {
+		p.line(gate(g))
+	}
+}
+
+func nodes[N Node](list []N) []Node {
+	ret := make([]Node, len(list))
+	for i, n := range list {
+		ret[i] = n
+	}
+	return ret
+}
+
+// Items
+
+func (p *printer) item(n Node) {
+	docs, gates := leading(n)
+	p.leading(docs, gates)
+
+	switch n := n.(type) {
+	case *Package:
+		p.block("package "+packageName(n), inSourceOrder(nodes(n.Interfaces), nodes(n.Worlds)))
+
+	case *Interface:
+		p.block("interface "+name(n.Name, false), interfaceItems(&n.Items))
+
+	case *World:
+		p.block("world "+name(n.Name, false), nodes(n.Items()))
+
+	case *Use:
+		ui := n.UseInterface
+		switch {
+		case len(ui.Items) > 0:
+			p.line("use " + useInterface(&ui) + ";")
+		case n.Identifier != nil && n.Identifier.Alias != "":
+			p.line("use " + usePath(ui.Path) + " as " + name(n.Identifier.Alias, false) + ";")
+		default:
+			p.line("use " + usePath(ui.Path) + ";")
+		}
+
+	case *UseShape:
+		p.line("use " + useInterface(&n.UseInterface) + ";")
+
+	case *TypeDef:
+		p.typeDefKind(n.Kind)
+
+	case *FuncShape:
+		p.line(funcShape(n) + ";")
+
+	case constructor:
+		p.line("constructor" + params(n.Func.ParamList) + results(n.Func.ResultList) + ";")
+
+	case *ImportShape:
+		p.extern("import", target(n.Name, n.Path), n.Func, n.Interface)
+
+	case *ExportShape:
+		p.extern("export", target(n.Name, n.Path), n.Func, n.Interface)
+
+	case *IncludeShape:
+		s := "include " + target(n.Name, n.Path)
+		if len(n.With) > 0 {
+			with := make([]string, len(n.With))
+			for i := range n.With {
+				with[i] = ident(&n.With[i]) + " as " + name(n.With[i].Alias, false)
+			}
+			p.line(s + " with { " + strings.Join(with, ", ") + " }")
+			return
+		}
+		p.line(s + ";")
+	}
+}
+
+func (p *printer) packageDecl(pkg *Package) {
+	p.leading(pkg.Docs, nil)
+	p.line("package " + packageName(pkg) + ";")
+}
+
+func packageName(pkg *Package) string {
+	s := name(pkg.Namespace, false) + ":" + name(pkg.Name, false)
+	if pkg.Version != nil {
+		s += "@" + pkg.Version.String()
+	}
+	return s
+}
+
+func interfaceItems(ii *InterfaceItems) []Node {
+	return inSourceOrder(nodes(ii.UseItems), nodes(ii.TypedefItems), nodes(ii.FuncItems))
+}
+
+// extern prints an import or export of an interface by name, of a function
+// or of an inline interface.
+func (p *printer) extern(keyword, name string, ft *FuncType, ii *InterfaceItems) {
+	header := keyword + " " + name
+	switch {
+	case ft != nil:
+		p.line(header + ": " + funcType(ft) + ";")
+	case ii != nil:
+		p.block(header+": interface", interfaceItems(ii))
+	default:
+		p.line(header + ";")
+	}
+}
+
+// target returns the path an import, export or include refers to, or its
+// name if it has none.
+func target(n *Identifier, up *UsePath) string {
+	if up != nil {
+		return usePath(up)
+	}
+	return ident(n)
+}
+
+func useInterface(ui *UseInterface) string {
+	names := make([]string, len(ui.Items))
+	for i := range ui.Items {
+		names[i] = ident(&ui.Items[i])
+		if alias := ui.Items[i].Alias; alias != "" {
+			names[i] += " as " + name(alias, false)
+		}
+	}
+	return usePath(ui.Path) + ".{" + strings.Join(names, ", ") + "}"
+}
+
+func usePath(up *UsePath) string {
+	if !up.IsQualified() {
+		return ident(up.Name)
+	}
+
+	s := ident(up.Namespace) + ":" + ident(up.Package) + "/" + ident(up.Name)
+	if up.Version != nil {
+		s += "@" + up.Version.String()
+	}
+	return s
+}
+
+func gate(g *Gate) string {
+	var args []string
+	if g.Version != nil {
+		args = append(args, "version = "+g.Version.String())
+	}
+	if g.Feature != nil {
+		args = append(args, "feature = "+ident(g.Feature))
+	}
+	return "@" + ident(g.Name) + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Type definitions
+
+func (p *printer) typeDefKind(k TypeDefKind) {
+	switch k := k.(type) {
+	case *TypeShape:
+		p.line("type " + ident(k.Name) + " = " + typ(k.Value) + ";")
+
+	case *RecordShape:
+		p.members("record "+ident(k.Identifier), len(k.Fields), func(i int) {
+			p.leading(k.Fields[i].Docs, k.Fields[i].Gates)
+			p.line(field(k.Fields[i]) + ",")
+		})
+
+	case *VariantShape:
+		p.members("variant "+ident(k.Identifier), len(k.Cases), func(i int) {
+			p.leading(k.Cases[i].Docs, k.Cases[i].Gates)
+			p.line(variantCase(k.Cases[i]) + ",")
+		})
+
+	case *EnumShape:
+		p.members("enum "+ident(k.Name), len(k.Cases), func(i int) {
+			p.leading(k.Cases[i].Docs, k.Cases[i].Gates)
+			p.line(ident(k.Cases[i].Identifier) + ",")
+		})
+
+	case *FlagShape:
+		p.members("flags "+ident(k.Name), len(k.Flags), func(i int) {
+			p.leading(k.Flags[i].Docs, k.Flags[i].Gates)
+			p.line(ident(k.Flags[i].Identifier) + ",")
+		})
+
+	case *UnionShape:
+		p.members("union "+ident(k.Name), len(k.Cases), func(i int) {
+			p.line(typ(k.Cases[i]) + ",")
+		})
+
+	case *ResourceShape:
+		funcs := resourceFuncs(k)
+		if len(funcs) == 0 {
+			p.line("resource " + ident(k.Name) + ";")
+			return
+		}
+		p.block("resource "+ident(k.Name), funcs)
+	}
+}
+
+func resourceFuncs(r *ResourceShape) []Node {
+	var ctor []Node
+	if r.Constructor != nil {
+		ctor = append(ctor, r.Constructor)
+	}
+	funcs := inSourceOrder(ctor, nodes(r.Methods), nodes(r.StaticFuncs))
+
+	// the constructor is printed with its keyword rather than a name
+	for i, f := range funcs {
+		if f == Node(r.Constructor) {
+			funcs[i] = constructor{r.Constructor}
+		}
+	}
+	return funcs
+}
+
+// constructor wraps the constructor of a resource so that item prints it
+// with the constructor keyword rather than its name.
+type constructor struct{ *FuncShape }
+
+func field(f *RecordField) string {
+	return ident(f.Identifier) + ": " + typ(f.Type)
+}
+
+func variantCase(c *VariantCase) string {
+	if c.Type == nil {
+		return ident(c.Identifier)
+	}
+	return ident(c.Identifier) + "(" + typ(c.Type) + ")"
+}
+
+// Functions
+
+func funcShape(f *FuncShape) string {
+	s := ident(f.Name) + ": "
+	if f.Static {
+		s += "static "
+	}
+	return s + funcType(f.Func)
+}
+
+func funcType(ft *FuncType) string {
+	if ft == nil {
+		return "func()"
+	}
+	return "func" + params(ft.ParamList) + results(ft.ResultList)
+}
+
+func params(pl *ParamList) string {
+	if pl == nil {
+		return "()"
+	}
+	return "(" + namedTypes(*pl) + ")"
+}
+
+// results returns the results of a function including the leading ` -> `,
+// or an empty string when the function has none.
+func results(rl *ResultList) string {
+	switch {
+	case rl == nil:
+		return ""
+	case len(*rl) == 1 && (*rl)[0].Name == nil:
+		return " -> " + typ((*rl)[0].Type)
+	default:
+		return " -> (" + namedTypes(*rl) + ")"
+	}
+}
+
+func namedTypes(list []*NamedType) string {
+	ret := make([]string, len(list))
+	for i, nt := range list {
+		ret[i] = namedType(nt)
+	}
+	return strings.Join(ret, ", ")
+}
+
+func namedType(nt *NamedType) string {
+	if nt.Name == nil {
+		return typ(nt.Type)
+	}
+	return ident(nt.Name) + ": " + typ(nt.Type)
+}
+
+// Types
+
+func typ(t Type) string {
+	switch t := t.(type) {
+	case *Primitive:
+		if t.Token.Literal != "" {
+			return t.Token.Literal
+		}
+		return strings.ToLower(string(t.Token.Type))
+
+	case *Named:
+		return ident(t.Name)
+
+	case *List:
+		return "list<" + typ(t.Elem) + ">"
+
+	case *Option:
+		return "option<" + typ(t.Elem) + ">"
+
+	case *Result:
+		switch {
+		case t.Ok == nil && t.Err == nil:
+			return "result"
+		case t.Err == nil:
+			return "result<" + typ(t.Ok) + ">"
+		case t.Ok == nil:
+			return "result<_, " + typ(t.Err) + ">"
+		default:
+			return "result<" + typ(t.Ok) + ", " + typ(t.Err) + ">"
+		}
+
+	case *Tuple:
+		elems := make([]string, len(t.Elems))
+		for i, e := range t.Elems {
+			elems[i] = typ(e)
+		}
+		return "tuple<" + strings.Join(elems, ", ") + ">"
+
+	case *Handle:
+		if t.Implicit {
+			return ident(t.Resource)
+		}
+		if t.Kind == token.KEYWORD_BORROW {
+			return "borrow<" + ident(t.Resource) + ">"
+		}
+		return "own<" + ident(t.Resource) + ">"
+
+	case *Future:
+		if t.Elem == nil {
+			return "future"
+		}
+		return "future<" + typ(t.Elem) + ">"
+
+	case *Stream:
+		if t.Elem == nil {
+			return "stream"
+		}
+		return "stream<" + typ(t.Elem) + ">"
+	}
+	return ""
+}
+
+// Identifiers
+
+func ident(id *Identifier) string {
+	return name(id.Value, id.Token.Explicit)
+}
+
+// name returns s as written in WIT. Keywords, and identifiers written with
+// a leading `%`, are prefixed with `%`.
+func name(s string, explicit bool) string {
+	if explicit || token.LookupIdentifier(s) != token.IDENTIFIER {
+		return "%" + s
+	}
+	return s
+}
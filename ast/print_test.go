@@ -0,0 +1,341 @@
+package ast_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/parser"
+	"github.com/jordan-rash/go-wit/resolver"
+	"github.com/jordan-rash/go-wit/token"
+	"github.com/stretchr/testify/assert"
+)
+
+const input = `/// The demo package
+package local:demo@0.2.0;
+
+use wasi:io/streams@0.2.0.{input-stream as in-stream}
+use wasi:clocks/wall-clock@0.2.0 as clock
+use wasi:io/poll@0.2.0
+
+/// Shared types
+@since(version = 0.2.0)
+interface types {
+  use streams.{error}
+  type id = u64
+  type %type = string
+  /** Pairs
+   * of things */
+  record pair {
+    /// The left side
+    left: list<option<tuple<u8, string>>>,
+    @unstable(feature = right)
+    right: result<_, error>
+  }
+  variant shape { circle(f32), square(float64), none }
+  enum color { red, green, @since(version = 0.2.1) blue }
+  flags perms {
+    /// May read
+    read,
+    write
+  }
+  union num { u32, s64 }
+  resource handle;
+  resource blob {
+    constructor(init: list<u8>)
+    /// Reads n bytes
+    read: func(n: u32) -> result<list<u8>>
+    size: func() -> (len: u64, ok: bool)
+    merge: static func(a: borrow<blob>, b: own<blob>) -> blob
+    close: func() -> ()
+  }
+  type events = stream<future<result>>
+  type done = future
+  %interface: func(%record: char) -> bool
+}
+
+world demo {
+  import wasi:cli/environment@0.2.0
+  import log: func(msg: string)
+  export run: func() -> result
+  @deprecated(version = 0.2.0)
+  export types
+  use types.{pair, id as ident}
+  type alias = pair
+  import inline: interface {
+    ping: func()
+  }
+  include wasi:cli/imports@0.2.0 with { environment as env, stdout as out }
+}
+
+package local:nested {
+  interface empty {}
+
+  world other {}
+}`
+
+const canonical = `/// The demo package
+package local:demo@0.2.0;
+
+use wasi:io/streams@0.2.0.{input-stream as in-stream};
+use wasi:clocks/wall-clock@0.2.0 as clock;
+use wasi:io/poll@0.2.0;
+
+/// Shared types
+@since(version = 0.2.0)
+interface types {
+  use streams.{error};
+
+  type id = u64;
+  type %type = string;
+
+  /** Pairs
+   * of things */
+  record pair {
+    /// The left side
+    left: list<option<tuple<u8, string>>>,
+    @unstable(feature = right)
+    right: result<_, error>,
+  }
+
+  variant shape {
+    circle(f32),
+    square(float64),
+    none,
+  }
+
+  enum color {
+    red,
+    green,
+    @since(version = 0.2.1)
+    blue,
+  }
+
+  flags perms {
+    /// May read
+    read,
+    write,
+  }
+
+  union num {
+    u32,
+    s64,
+  }
+
+  resource handle;
+
+  resource blob {
+    constructor(init: list<u8>);
+
+    /// Reads n bytes
+    read: func(n: u32) -> result<list<u8>>;
+    size: func() -> (len: u64, ok: bool);
+    merge: static func(a: borrow<blob>, b: own<blob>) -> blob;
+    close: func() -> ();
+  }
+
+  type events = stream<future<result>>;
+  type done = future;
+
+  %interface: func(%record: char) -> bool;
+}
+
+world demo {
+  import wasi:cli/environment@0.2.0;
+  import log: func(msg: string);
+
+  export run: func() -> result;
+
+  @deprecated(version = 0.2.0)
+  export types;
+
+  use types.{pair, id as ident};
+
+  type alias = pair;
+
+  import inline: interface {
+    ping: func();
+  }
+
+  include wasi:cli/imports@0.2.0 with { environment as env, stdout as out }
+}
+
+package local:nested {
+  interface empty {}
+
+  world other {}
+}
+`
+
+// sprint returns node printed as by ast.Fprint, or an empty string if node
+// can't be printed.
+func sprint(node ast.Node) string {
+	var sb strings.Builder
+	if err := ast.Fprint(&sb, node); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+func parse(t *testing.T, src string) *ast.AST {
+	t.Helper()
+
+	tree, err := parser.ParseString("demo.wit", src)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return tree
+}
+
+// clearPositions zeroes every position reachable from v, so that trees
+// parsed from differently formatted sources compare equal.
+func clearPositions(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			clearPositions(v.Elem())
+		}
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(token.Position{}) {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			clearPositions(v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			clearPositions(v.Index(i))
+		}
+	}
+}
+
+// roundTrip prints tree, parses the output and checks that the result
+// equals tree apart from positions. It returns the printed source.
+func roundTrip(t *testing.T, tree *ast.AST) string {
+	t.Helper()
+
+	var sb strings.Builder
+	if !assert.NoError(t, ast.Fprint(&sb, tree)) {
+		return ""
+	}
+	out := sb.String()
+	again := parse(t, out)
+
+	// printing is idempotent
+	assert.Equal(t, out, sprint(again))
+
+	clearPositions(reflect.ValueOf(tree))
+	clearPositions(reflect.ValueOf(again))
+	assert.Equal(t, tree, again)
+	return out
+}
+
+func TestFprint(t *testing.T) {
+	assert.Equal(t, canonical, roundTrip(t, parse(t, input)))
+}
+
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../cmd/simple/*.wit")
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, files) {
+		return
+	}
+
+	for _, f := range files {
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			b, err := os.ReadFile(f)
+			if !assert.NoError(t, err) {
+				return
+			}
+			roundTrip(t, parse(t, string(b)))
+		})
+	}
+}
+
+func TestFprintNodes(t *testing.T) {
+	tree := parse(t, input)
+	types := tree.Interfaces[0]
+
+	blob := types.Items.TypedefItems[8].Kind.(*ast.ResourceShape)
+	assert.Equal(t, "-> result<list<u8>>", sprint(blob.Methods[0].Func.ResultList))
+	assert.Equal(t, "(a: borrow<blob>, b: own<blob>)", sprint(blob.StaticFuncs[0].Func.ParamList))
+	assert.Equal(t, "enum color {\n  red,\n  green,\n  @since(version = 0.2.1)\n  blue,\n}\n", sprint(types.Items.TypedefItems[4]))
+	assert.Equal(t, "@since(version = 0.2.0)\n", sprint(types.Gates[0]))
+	assert.Equal(t, "wasi:io/streams@0.2.0", sprint(tree.Uses[0].UseInterface.Path))
+
+	// a package without items is a declaration
+	assert.Equal(t, "/// The demo package\npackage local:demo@0.2.0;\n", sprint(tree.Package))
+
+	// keywords used as names are escaped, even in nodes created by hand
+	id := &ast.Identifier{Value: "flags"}
+	assert.Equal(t, "%flags", sprint(id))
+	assert.Equal(t, "list<%flags>", sprint(&ast.List{Elem: &ast.Named{Name: id}}))
+
+	assert.Error(t, ast.Fprint(new(strings.Builder), &unknown{}))
+}
+
+// unknown is a node Fprint knows nothing about.
+type unknown struct{ ast.Identifier }
+
+func TestFprintResolved(t *testing.T) {
+	tree := parse(t, `package local:demo;
+
+interface types {
+  resource blob;
+  read: func(b: blob) -> own<blob>
+}`)
+
+	if !assert.Empty(t, resolver.Resolve(tree)) {
+		return
+	}
+
+	// implicit handles made by the resolver print as the resource name
+	read := tree.Interfaces[0].Items.FuncItems[0]
+	if hs, ok := (*read.Func.ParamList)[0].Type.(*ast.Handle); assert.True(t, ok) {
+		assert.True(t, hs.Implicit)
+	}
+	assert.Equal(t, "read: func(b: blob) -> own<blob>;\n", sprint(read))
+}
+
+func TestASTString(t *testing.T) {
+	tree := parse(t, input)
+	assert.Equal(t, canonical, tree.String())
+
+	// items added by hand follow the item before them
+	w := tree.World("demo")
+	w.ImportItems = append(w.ImportItems, &ast.ImportShape{Name: &ast.Identifier{Value: "extra"}})
+	assert.Contains(t, tree.String(), "    ping: func();\n  }\n\n  import extra;\n\n  include")
+
+	// a tree built by hand renders without the parser
+	id := func(s string) *ast.Identifier { return &ast.Identifier{Value: s} }
+	tree = &ast.AST{
+		Package: &ast.Package{Namespace: "local", Name: "demo", Version: &ast.Version{Minor: 1}},
+		Interfaces: []*ast.Interface{{
+			Name: "types",
+			Items: ast.InterfaceItems{
+				TypedefItems: []*ast.TypeDef{{
+					Kind: &ast.TypeShape{
+						Name:  id("ids"),
+						Value: &ast.List{Elem: &ast.Primitive{Token: token.Token{Type: token.KEYWORD_U64}}},
+					},
+				}},
+				FuncItems: []*ast.FuncShape{{
+					Name: id("type"),
+					Func: &ast.FuncType{ParamList: &ast.ParamList{}},
+				}},
+			},
+		}},
+	}
+
+	assert.Equal(t, `package local:demo@0.1.0;
+
+interface types {
+  type ids = list<u64>;
+
+  %type: func();
+}
+`, tree.String())
+}
@@ -5,263 +5,488 @@ import (
 )
 
 type Identifier struct {
-	Location int
-
 	Token token.Token
 	Alias string
 	Value string
 }
 
-func (t *Identifier) expressionNode()      {}
 func (t *Identifier) Validate() bool       { return true }
 func (t *Identifier) TokenLiteral() string { return t.Token.Literal }
+func (t *Identifier) Pos() token.Position  { return t.Token.Pos }
+func (t *Identifier) End() token.Position  { return t.Token.End }
 
-type Ty struct {
-	Name  *Identifier
+// Types
+
+// Type is a WIT type. It is implemented by Primitive, Named, List, Option,
+// Result, Tuple, Handle, Future and Stream only.
+type Type interface {
+	Node
+	typeNode()
+}
+
+// Primitive is one of the built-in types such as `u32`, `bool` or
+// `string`. Token.Type is the keyword of the type.
+type Primitive struct {
 	Token token.Token
-	Value Expression
 }
 
-func (t *Ty) expressionNode()      {}
-func (t *Ty) Validate() bool       { return true }
-func (t *Ty) TokenLiteral() string { return t.Token.Literal }
+func (t *Primitive) typeNode()            {}
+func (t *Primitive) Validate() bool       { return true }
+func (t *Primitive) TokenLiteral() string { return t.Token.Literal }
+func (t *Primitive) Pos() token.Position  { return t.Token.Pos }
+func (t *Primitive) End() token.Position  { return t.Token.End }
 
-// Root shapes
+// Named refers to a type by name, such as a type definition or a type
+// brought into scope by `use`.
+type Named struct {
+	Name *Identifier
+}
+
+func (t *Named) typeNode()            {}
+func (t *Named) Validate() bool       { return t.Name != nil }
+func (t *Named) TokenLiteral() string { return t.Name.Token.Literal }
+func (t *Named) Pos() token.Position  { return t.Name.Pos() }
+func (t *Named) End() token.Position  { return t.Name.End() }
 
-type TopUseShape struct {
+// List is `list<T>`.
+type List struct {
 	Token token.Token
-	Name  *Identifier
-	Value Expression //TODO this should be a Shape
+	Elem  Type
+	Span  token.Span
 }
 
-func (t *TopUseShape) useNode()             {}
-func (t *TopUseShape) TokenLiteral() string { return t.Token.Literal }
+func (t *List) typeNode()            {}
+func (t *List) Validate() bool       { return t.Elem != nil }
+func (t *List) TokenLiteral() string { return t.Token.Literal }
+func (t *List) Pos() token.Position  { return t.Span.Start }
+func (t *List) End() token.Position  { return t.Span.End }
+
+// Option is `option<T>`.
+type Option struct {
+	Token token.Token
+	Elem  Type
+	Span  token.Span
+}
 
-// Secondary interface shapes
+func (t *Option) typeNode()            {}
+func (t *Option) Validate() bool       { return t.Elem != nil }
+func (t *Option) TokenLiteral() string { return t.Token.Literal }
+func (t *Option) Pos() token.Position  { return t.Span.Start }
+func (t *Option) End() token.Position  { return t.Span.End }
 
-type TypeDef struct {
+// Result is `result`, `result<T>`, `result<_, E>` or `result<T, E>`. Ok
+// and Err are nil when omitted.
+type Result struct {
 	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Ok    Type
+	Err   Type
+	Span  token.Span
 }
 
-func (t *TypeDef) expressionNode()      {}
-func (t *TypeDef) Validate() bool       { return true }
-func (t *TypeDef) TokenLiteral() string { return t.Token.Literal }
+func (t *Result) typeNode()            {}
+func (t *Result) Validate() bool       { return true }
+func (t *Result) TokenLiteral() string { return t.Token.Literal }
+func (t *Result) Pos() token.Position  { return t.Span.Start }
+func (t *Result) End() token.Position  { return t.Span.End }
 
-type TypeShape struct {
+// Tuple is `tuple<T, U, ...>`.
+type Tuple struct {
 	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Elems []Type
+	Span  token.Span
 }
 
-type UseShape struct {
+func (t *Tuple) typeNode()            {}
+func (t *Tuple) Validate() bool       { return len(t.Elems) > 0 }
+func (t *Tuple) TokenLiteral() string { return t.Token.Literal }
+func (t *Tuple) Pos() token.Position  { return t.Span.Start }
+func (t *Tuple) End() token.Position  { return t.Span.End }
+
+// Handle is a handle to a resource, written `own<r>` or `borrow<r>`.
+// Resource names used directly as a type are owned handles; the resolver
+// turns those into Handles marked as Implicit.
+type Handle struct {
+	Token    token.Token
+	Kind     token.TokenType // KEYWORD_OWN or KEYWORD_BORROW
+	Resource *Identifier
+	Implicit bool
+	Span     token.Span
+}
+
+func (t *Handle) typeNode() {}
+func (t *Handle) Validate() bool {
+	return t.Kind == token.KEYWORD_OWN || t.Kind == token.KEYWORD_BORROW
+}
+func (t *Handle) TokenLiteral() string { return t.Token.Literal }
+func (t *Handle) Pos() token.Position  { return t.Span.Start }
+func (t *Handle) End() token.Position  { return t.Span.End }
+
+// Future is `future` or `future<T>`. Elem is nil when the future carries
+// no payload.
+type Future struct {
 	Token token.Token
-	Name  *Identifier
-	Value Expression //TODO this should be a Shape
+	Elem  Type
+	Span  token.Span
 }
 
-func (t *UseShape) interfaceNode()       {}
-func (t *UseShape) TokenLiteral() string { return t.Token.Literal }
+func (t *Future) typeNode()            {}
+func (t *Future) Validate() bool       { return true }
+func (t *Future) TokenLiteral() string { return t.Token.Literal }
+func (t *Future) Pos() token.Position  { return t.Span.Start }
+func (t *Future) End() token.Position  { return t.Span.End }
 
-func (t *TypeShape) expressionNode()      {}
-func (t *TypeShape) Validate() bool       { return true }
-func (t *TypeShape) TokenLiteral() string { return t.Token.Literal }
+// Stream is `stream` or `stream<T>`. Elem is nil when the stream carries
+// no elements.
+type Stream struct {
+	Token token.Token
+	Elem  Type
+	Span  token.Span
+}
+
+func (t *Stream) typeNode()            {}
+func (t *Stream) Validate() bool       { return true }
+func (t *Stream) TokenLiteral() string { return t.Token.Literal }
+func (t *Stream) Pos() token.Position  { return t.Span.Start }
+func (t *Stream) End() token.Position  { return t.Span.End }
+
+// Type definitions
 
-type ListShape struct {
+// TypeDef is a named type definition in an interface or world.
+type TypeDef struct {
 	Token token.Token
+	Docs  *Docs
+	Gates Gates
 	Name  *Identifier
-	Value Expression
+	Kind  TypeDefKind
+	Span  token.Span
 }
 
-func (t *ListShape) expressionNode()      {}
-func (t *ListShape) Validate() bool       { return true }
-func (t *ListShape) TokenLiteral() string { return t.Token.Literal }
+func (t *TypeDef) worldItem()           {}
+func (t *TypeDef) Validate() bool       { return t.Name != nil && t.Kind != nil }
+func (t *TypeDef) TokenLiteral() string { return t.Token.Literal }
+func (t *TypeDef) Pos() token.Position  { return t.Span.Start }
+func (t *TypeDef) End() token.Position  { return t.Span.End }
+
+// TypeDefKind is what a type definition defines. It is implemented by
+// TypeShape, RecordShape, VariantShape, EnumShape, FlagShape, UnionShape and
+// ResourceShape only.
+type TypeDefKind interface {
+	Node
+	typeDefKind()
+}
 
-type OptionShape struct {
+// TypeShape is a type alias, `type name = ty`.
+type TypeShape struct {
 	Token token.Token
 	Name  *Identifier
-	Value Expression
+	Value Type
+	Span  token.Span
 }
 
-func (t *OptionShape) expressionNode()      {}
-func (t *OptionShape) Validate() bool       { return true }
-func (t *OptionShape) TokenLiteral() string { return t.Token.Literal }
+func (t *TypeShape) typeDefKind()         {}
+func (t *TypeShape) Validate() bool       { return t.Value != nil }
+func (t *TypeShape) TokenLiteral() string { return t.Token.Literal }
+func (t *TypeShape) Pos() token.Position  { return t.Span.Start }
+func (t *TypeShape) End() token.Position  { return t.Span.End }
 
-type ResultShape struct {
-	Token    token.Token
-	Name     *Identifier
-	OkValue  Expression
-	ErrValue Expression
+type RecordShape struct {
+	Token      token.Token
+	Identifier *Identifier
+	Fields     []*RecordField
+	Span       token.Span
 }
 
-func (t *ResultShape) expressionNode()      {}
-func (t *ResultShape) Validate() bool       { return true }
-func (t *ResultShape) TokenLiteral() string { return t.Token.Literal }
+func (t *RecordShape) typeDefKind()         {}
+func (t *RecordShape) Validate() bool       { return true }
+func (t *RecordShape) TokenLiteral() string { return t.Token.Literal }
+func (t *RecordShape) Pos() token.Position  { return t.Span.Start }
+func (t *RecordShape) End() token.Position  { return t.Span.End }
 
-type TupleShape struct {
-	Token token.Token
-	Name  *Identifier
-	Value []Expression
+type RecordField struct {
+	Token      token.Token
+	Docs       *Docs
+	Gates      Gates
+	Identifier *Identifier
+	Type       Type
+	Span       token.Span
 }
 
-func (t *TupleShape) expressionNode()      {}
-func (t *TupleShape) Validate() bool       { return true }
-func (t *TupleShape) TokenLiteral() string { return t.Token.Literal }
+func (t *RecordField) Validate() bool       { return t.Type != nil }
+func (t *RecordField) TokenLiteral() string { return t.Token.Literal }
+func (t *RecordField) Pos() token.Position  { return t.Span.Start }
+func (t *RecordField) End() token.Position  { return t.Span.End }
 
-type ExportShape struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+type VariantShape struct {
+	Token      token.Token
+	Identifier *Identifier
+	Cases      []*VariantCase
+	Span       token.Span
 }
 
-func (t *ExportShape) worldNode()           {}
-func (t *ExportShape) Validate() bool       { return true }
-func (t *ExportShape) TokenLiteral() string { return t.Token.Literal }
+func (t *VariantShape) typeDefKind()         {}
+func (t *VariantShape) Validate() bool       { return len(t.Cases) > 0 }
+func (t *VariantShape) TokenLiteral() string { return t.Token.Literal }
+func (t *VariantShape) Pos() token.Position  { return t.Span.Start }
+func (t *VariantShape) End() token.Position  { return t.Span.End }
 
-type ImportShape struct {
-	Token token.Token
+// VariantCase is a case of a variant. Type is nil when the case carries no
+// payload.
+type VariantCase struct {
+	Token      token.Token
+	Docs       *Docs
+	Gates      Gates
+	Identifier *Identifier
+	Type       Type
+	Span       token.Span
+}
+
+func (t *VariantCase) Validate() bool       { return true }
+func (t *VariantCase) TokenLiteral() string { return t.Token.Literal }
+func (t *VariantCase) Pos() token.Position  { return t.Span.Start }
+func (t *VariantCase) End() token.Position  { return t.Span.End }
+
+type EnumShape struct {
 	Name  *Identifier
-	Value Expression
+	Token token.Token
+
+	Cases []*EnumCase
+	Span  token.Span
 }
 
-func (t *ImportShape) worldNode()           {}
-func (t *ImportShape) TokenLiteral() string { return t.Token.Literal }
+func (t *EnumShape) typeDefKind()         {}
+func (t *EnumShape) Validate() bool       { return len(t.Cases) > 0 }
+func (t *EnumShape) TokenLiteral() string { return t.Token.Literal }
+func (t *EnumShape) Pos() token.Position  { return t.Span.Start }
+func (t *EnumShape) End() token.Position  { return t.Span.End }
 
-type IncludeShape struct {
+// EnumCase is a case of an enum.
+type EnumCase struct {
+	Docs       *Docs
+	Gates      Gates
+	Identifier *Identifier
+	Span       token.Span
+}
+
+func (t *EnumCase) Validate() bool       { return t.Identifier != nil }
+func (t *EnumCase) TokenLiteral() string { return t.Identifier.Token.Literal }
+func (t *EnumCase) Pos() token.Position  { return t.Span.Start }
+func (t *EnumCase) End() token.Position  { return t.Span.End }
+
+type FlagShape struct {
+	Name  *Identifier
 	Token token.Token
+
+	Flags []*Flag
+	Span  token.Span
+}
+
+func (t *FlagShape) typeDefKind()         {}
+func (t *FlagShape) Validate() bool       { return true }
+func (t *FlagShape) TokenLiteral() string { return t.Token.Literal }
+func (t *FlagShape) Pos() token.Position  { return t.Span.Start }
+func (t *FlagShape) End() token.Position  { return t.Span.End }
+
+// Flag is a flag of a flags type.
+type Flag struct {
+	Docs       *Docs
+	Gates      Gates
+	Identifier *Identifier
+	Span       token.Span
+}
+
+func (t *Flag) Validate() bool       { return t.Identifier != nil }
+func (t *Flag) TokenLiteral() string { return t.Identifier.Token.Literal }
+func (t *Flag) Pos() token.Position  { return t.Span.Start }
+func (t *Flag) End() token.Position  { return t.Span.End }
+
+type UnionShape struct {
 	Name  *Identifier
-	Value Expression
+	Token token.Token
+
+	Cases []Type
+	Span  token.Span
 }
 
-func (t *IncludeShape) worldNode()           {}
-func (t *IncludeShape) TokenLiteral() string { return t.Token.Literal }
+func (t *UnionShape) typeDefKind()         {}
+func (t *UnionShape) Validate() bool       { return len(t.Cases) > 0 }
+func (t *UnionShape) TokenLiteral() string { return t.Token.Literal }
+func (t *UnionShape) Pos() token.Position  { return t.Span.Start }
+func (t *UnionShape) End() token.Position  { return t.Span.End }
+
+// ResourceShape is a resource type and its functions. The constructor is a
+// FuncShape named after the `constructor` keyword whose results, if any,
+// are a single result type.
+type ResourceShape struct {
+	Token       token.Token
+	Name        *Identifier
+	Constructor *FuncShape // nil when the resource has no constructor
+	Methods     []*FuncShape
+	StaticFuncs []*FuncShape
+	Span        token.Span
+}
+
+func (t *ResourceShape) typeDefKind()         {}
+func (t *ResourceShape) Validate() bool       { return true }
+func (t *ResourceShape) TokenLiteral() string { return t.Token.Literal }
+func (t *ResourceShape) Pos() token.Position  { return t.Span.Start }
+func (t *ResourceShape) End() token.Position  { return t.Span.End }
+
+// Functions
 
 type FuncShape struct {
 	Token  token.Token
+	Docs   *Docs
+	Gates  Gates
 	Name   *Identifier
 	Static bool
-	Value  Expression
+	Func   *FuncType
+	Span   token.Span
 }
 
-func (t *FuncShape) expressionNode()      {}
-func (t *FuncShape) Validate() bool       { return true }
+func (t *FuncShape) Validate() bool       { return t.Func != nil }
 func (t *FuncShape) TokenLiteral() string { return t.Token.Literal }
+func (t *FuncShape) Pos() token.Position  { return t.Span.Start }
+func (t *FuncShape) End() token.Position  { return t.Span.End }
 
 type FuncType struct {
 	Token      token.Token
-	Name       *Identifier
 	ParamList  *ParamList
-	ResultList *ResultList
+	ResultList *ResultList // nil without `->`, empty for `-> ()`
+	Span       token.Span
 }
 
-func (t *FuncType) expressionNode()      {}
-func (t *FuncType) Validate() bool       { return true }
+func (t *FuncType) Validate() bool       { return t.ParamList != nil }
 func (t *FuncType) TokenLiteral() string { return t.Token.Literal }
+func (t *FuncType) Pos() token.Position  { return t.Span.Start }
+func (t *FuncType) End() token.Position  { return t.Span.End }
 
-type ParamList []Expression
+type ParamList []*NamedType
 
-func (t *ParamList) expressionNode()      {}
 func (t *ParamList) Validate() bool       { return true }
 func (t *ParamList) TokenLiteral() string { return "" }
+func (t *ParamList) Pos() token.Position  { return listPos(*t) }
+func (t *ParamList) End() token.Position  { return listEnd(*t) }
 
-type ResultList []Expression
+// ResultList holds the results of a function. A single unnamed result, as
+// in `-> u32`, has a nil Name.
+type ResultList []*NamedType
 
-func (t *ResultList) expressionNode()      {}
 func (t *ResultList) Validate() bool       { return true }
 func (t *ResultList) TokenLiteral() string { return "" }
+func (t *ResultList) Pos() token.Position  { return listPos(*t) }
+func (t *ResultList) End() token.Position  { return listEnd(*t) }
 
-type ResourceShape struct {
-	Token token.Token
-	Name  *Identifier
-	Value []Expression
-}
-
-func (t *ResourceShape) interfaceNode()       {}
-func (t *ResourceShape) Validate() bool       { return true }
-func (t *ResourceShape) TokenLiteral() string { return t.Token.Literal }
-
+// NamedType is a named parameter or result of a function, `name: ty`.
 type NamedType struct {
 	Token token.Token
 	Name  *Identifier
-
-	Id Expression //
-	Ty Expression
+	Type  Type
+	Span  token.Span
 }
 
-func (t *NamedType) expressionNode()      {}
-func (t *NamedType) Validate() bool       { return true }
+func (t *NamedType) Validate() bool       { return t.Type != nil }
 func (t *NamedType) TokenLiteral() string { return t.Token.Literal }
+func (t *NamedType) Pos() token.Position  { return t.Span.Start }
+func (t *NamedType) End() token.Position  { return t.Span.End }
 
-type EnumShape struct {
-	Name  *Identifier
-	Token token.Token
+// World items
 
-	Value []Expression
+// WorldItem is an item of a world body. It is implemented by ImportShape,
+// ExportShape, UseShape, IncludeShape and TypeDef only.
+type WorldItem interface {
+	Node
+	worldItem()
 }
 
-func (t *EnumShape) expressionNode()      {}
-func (t *EnumShape) Validate() bool       { return true }
-func (t *EnumShape) TokenLiteral() string { return t.Token.Literal }
-
-type FlagShape struct {
-	Name  *Identifier
+type UseShape struct {
 	Token token.Token
-
-	Value []Expression
-}
-
-func (t *FlagShape) expressionNode()      {}
-func (t *FlagShape) Validate() bool       { return true }
-func (t *FlagShape) TokenLiteral() string { return t.Token.Literal }
-
-type UnionShape struct {
+	Docs  *Docs
+	Gates Gates
 	Name  *Identifier
-	Token token.Token
 
-	Value []Expression
+	UseInterface UseInterface
+	Span         token.Span
 }
 
-func (t *UnionShape) expressionNode()      {}
-func (t *UnionShape) Validate() bool       { return true }
-func (t *UnionShape) TokenLiteral() string { return t.Token.Literal }
+func (t *UseShape) worldItem()           {}
+func (t *UseShape) Validate() bool       { return true }
+func (t *UseShape) TokenLiteral() string { return t.Token.Literal }
+func (t *UseShape) Pos() token.Position  { return t.Span.Start }
+func (t *UseShape) End() token.Position  { return t.Span.End }
 
-type RecordShape struct {
-	Token      token.Token
-	Identifier *Identifier
-	Value      []Expression
+// ExportShape is an exported interface or function. Func is set for
+// `export name: func(...)`, Interface for `export name: interface { ... }`
+// and Path when an interface is exported by name. For a fully qualified
+// path Name spells out the whole path, as in `wasi:io/streams@0.2.0`.
+type ExportShape struct {
+	Token     token.Token
+	Docs      *Docs
+	Gates     Gates
+	Name      *Identifier
+	Path      *UsePath
+	Func      *FuncType
+	Interface *InterfaceItems
+	Span      token.Span
 }
 
-func (t *RecordShape) interfaceNode()       {}
-func (t *RecordShape) Validate() bool       { return true }
-func (t *RecordShape) TokenLiteral() string { return t.Token.Literal }
+func (t *ExportShape) worldItem()           {}
+func (t *ExportShape) Validate() bool       { return t.Func == nil || t.Interface == nil }
+func (t *ExportShape) TokenLiteral() string { return t.Token.Literal }
+func (t *ExportShape) Pos() token.Position  { return t.Span.Start }
+func (t *ExportShape) End() token.Position  { return t.Span.End }
 
-type VariantShape struct {
-	Token      token.Token
-	Identifier *Identifier
-	Value      []*VariantCase
+// ImportShape is an imported interface or function, see ExportShape.
+type ImportShape struct {
+	Token     token.Token
+	Docs      *Docs
+	Gates     Gates
+	Name      *Identifier
+	Path      *UsePath
+	Func      *FuncType
+	Interface *InterfaceItems
+	Span      token.Span
 }
 
-func (t *VariantShape) expressionNode()      {}
-func (t *VariantShape) Validate() bool       { return true }
-func (t *VariantShape) TokenLiteral() string { return t.Token.Literal }
+func (t *ImportShape) worldItem()           {}
+func (t *ImportShape) Validate() bool       { return t.Func == nil || t.Interface == nil }
+func (t *ImportShape) TokenLiteral() string { return t.Token.Literal }
+func (t *ImportShape) Pos() token.Position  { return t.Span.Start }
+func (t *ImportShape) End() token.Position  { return t.Span.End }
 
-type VariantCase struct {
-	Token      token.Token
-	Identifier *Identifier
-	Value      Expression
+// IncludeShape includes the world at Path. Name spells out the path, see
+// ExportShape.
+type IncludeShape struct {
+	Token token.Token
+	Docs  *Docs
+	Gates Gates
+	Name  *Identifier
+	Path  *UsePath
+	With  []Identifier // names renamed with `with { name as alias }`
+	Span  token.Span
 }
 
-func (t *VariantCase) expressionNode()      {}
-func (t *VariantCase) Validate() bool       { return true }
-func (t *VariantCase) TokenLiteral() string { return t.Token.Literal }
-
-type RecordField struct {
-	Token      token.Token
-	Identifier *Identifier
-	Ty         Expression
+func (t *IncludeShape) worldItem()           {}
+func (t *IncludeShape) Validate() bool       { return true }
+func (t *IncludeShape) TokenLiteral() string { return t.Token.Literal }
+func (t *IncludeShape) Pos() token.Position  { return t.Span.Start }
+func (t *IncludeShape) End() token.Position  { return t.Span.End }
+
+// listPos and listEnd report the span covered by the elements of a
+// ParamList or ResultList. Empty lists have no position.
+func listPos(l []*NamedType) token.Position {
+	for _, e := range l {
+		if e != nil {
+			return e.Pos()
+		}
+	}
+	return token.Position{}
 }
 
-func (t *RecordField) expressionNode()      {}
-func (t *RecordField) Validate() bool       { return true }
-func (t *RecordField) TokenLiteral() string { return t.Token.Literal }
+func listEnd(l []*NamedType) token.Position {
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i] != nil {
+			return l[i].End()
+		}
+	}
+	return token.Position{}
+}
@@ -0,0 +1,188 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version attached to a package or to a qualified
+// interface reference, see https://semver.org.
+type Version struct {
+	Major uint64
+	Minor uint64
+	Patch uint64
+	Pre   []string // dot separated pre-release identifiers
+	Build []string // dot separated build metadata identifiers
+}
+
+// ParseVersion parses s according to the semver grammar, e.g.
+// `0.2.0-rc-2023-11-10+build.5`.
+func ParseVersion(s string) (*Version, error) {
+	v := new(Version)
+
+	rest := s
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		build, err := splitIdentifiers(rest[i+1:], false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid build metadata in %q: %w", s, err)
+		}
+		v.Build, rest = build, rest[:i]
+	}
+
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		pre, err := splitIdentifiers(rest[i+1:], true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pre-release in %q: %w", s, err)
+		}
+		v.Pre, rest = pre, rest[:i]
+	}
+
+	core := strings.Split(rest, ".")
+	if len(core) != 3 {
+		return nil, fmt.Errorf("invalid version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+
+	for i, dst := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		n, err := parseNumeric(core[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		*dst = n
+	}
+
+	return v, nil
+}
+
+func (v *Version) String() string {
+	ret := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		ret += "-" + strings.Join(v.Pre, ".")
+	}
+	if len(v.Build) > 0 {
+		ret += "+" + strings.Join(v.Build, ".")
+	}
+	return ret
+}
+
+// Compare returns -1, 0 or +1 depending on whether v has lower, equal or
+// higher precedence than o. Build metadata does not affect precedence.
+func (v *Version) Compare(o *Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+
+	// a version without pre-release identifiers takes precedence
+	switch {
+	case len(v.Pre) == 0 && len(o.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Pre) && i < len(o.Pre); i++ {
+		if c := comparePre(v.Pre[i], o.Pre[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareUint(uint64(len(v.Pre)), uint64(len(o.Pre)))
+}
+
+// IsCompatible reports whether v and o are interchangeable under WIT's caret
+// rules: versions with a non-zero major version are compatible within that
+// major version, 0.y.z versions with a non-zero minor version are compatible
+// within that minor version, and 0.0.z and pre-release versions are only
+// compatible with themselves.
+func (v *Version) IsCompatible(o *Version) bool {
+	if len(v.Pre) > 0 || len(o.Pre) > 0 {
+		return v.Compare(o) == 0
+	}
+
+	switch {
+	case v.Major != 0:
+		return v.Major == o.Major
+	case v.Minor != 0:
+		return o.Major == 0 && v.Minor == o.Minor
+	default:
+		return o.Major == 0 && o.Minor == 0 && v.Patch == o.Patch
+	}
+}
+
+// splitIdentifiers splits dot separated pre-release or build identifiers.
+// Numeric pre-release identifiers may not have leading zeros.
+func splitIdentifiers(s string, pre bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+
+	for _, id := range ids {
+		if id == "" {
+			return nil, fmt.Errorf("empty identifier")
+		}
+
+		numeric := true
+		for _, c := range id {
+			switch {
+			case c >= '0' && c <= '9':
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-':
+				numeric = false
+			default:
+				return nil, fmt.Errorf("invalid character %q in %q", c, id)
+			}
+		}
+
+		if pre && numeric && len(id) > 1 && id[0] == '0' {
+			return nil, fmt.Errorf("leading zero in %q", id)
+		}
+	}
+
+	return ids, nil
+}
+
+func parseNumeric(s string) (uint64, error) {
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("leading zero in %q", s)
+	}
+
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+
+	return n, nil
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparePre compares pre-release identifiers: numeric identifiers compare
+// numerically and have lower precedence than alphanumeric ones.
+func comparePre(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareUint(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,71 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParseVersion(t *testing.T, s string) *ast.Version {
+	t.Helper()
+
+	v, err := ast.ParseVersion(s)
+	if !assert.NoError(t, err, s) {
+		t.FailNow()
+	}
+	return v
+}
+
+func TestParseVersion(t *testing.T) {
+	v := mustParseVersion(t, "0.2.0-rc-2023-11-10+build.5")
+	assert.Equal(t, uint64(0), v.Major)
+	assert.Equal(t, uint64(2), v.Minor)
+	assert.Equal(t, uint64(0), v.Patch)
+	assert.Equal(t, []string{"rc-2023-11-10"}, v.Pre)
+	assert.Equal(t, []string{"build", "5"}, v.Build)
+	assert.Equal(t, "0.2.0-rc-2023-11-10+build.5", v.String())
+
+	for _, s := range []string{"1.0", "1.0.0.0", "01.0.0", "1.0.0-", "1.0.0-01", "1.0.0+", "1.0.0-a..b", "1.0.0-a_b"} {
+		_, err := ast.ParseVersion(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// ordered by precedence, from https://semver.org/#spec-item-11
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0",
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		a, b := mustParseVersion(t, ordered[i-1]), mustParseVersion(t, ordered[i])
+		assert.Equal(t, -1, a.Compare(b), "%s < %s", a, b)
+		assert.Equal(t, 1, b.Compare(a), "%s > %s", b, a)
+	}
+
+	assert.Equal(t, 0, mustParseVersion(t, "1.0.0+a").Compare(mustParseVersion(t, "1.0.0+b")))
+}
+
+func TestVersionIsCompatible(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"1.2.3", "1.9.0", true},
+		{"1.2.3", "2.0.0", false},
+		{"0.2.0", "0.2.5", true},
+		{"0.2.0", "0.3.0", false},
+		{"0.0.1", "0.0.1", true},
+		{"0.0.1", "0.0.2", false},
+		{"0.2.0-rc.1", "0.2.0", false},
+		{"0.2.0-rc.1", "0.2.0-rc.1", true},
+	}
+
+	for _, tt := range tests {
+		a, b := mustParseVersion(t, tt.a), mustParseVersion(t, tt.b)
+		assert.Equal(t, tt.expected, a.IsCompatible(b), "%s ~ %s", a, b)
+		assert.Equal(t, tt.expected, b.IsCompatible(a), "%s ~ %s", b, a)
+	}
+}
@@ -0,0 +1,326 @@
+package ast
+
+import "fmt"
+
+// Visitor is implemented by callers of Walk. Walk calls Visit with every
+// node it reaches. When Visit returns another Visitor, Walk uses that one for
+// the children of the node and calls its Visit(nil) once they are done; when
+// it returns nil, the children are skipped.
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk visits the tree rooted at node depth first, with node itself first.
+// Node must not be nil, and children that are nil are left out. See Visitor
+// for how v decides which parts of the tree are visited.
+//
+// Children are visited in the order of the fields holding them. The
+// keyword tokens kept in the Identifier field of packages, worlds,
+// interfaces and top level uses are not visited; the names of uses, and of
+// imports, exports and includes referring to an interface or world by
+// path, are reached through their UsePath.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *AST:
+		if n.Package != nil {
+			Walk(v, n.Package)
+		}
+		walkList(v, n.Uses)
+		walkList(v, n.Interfaces)
+		walkList(v, n.Worlds)
+		walkList(v, n.Packages)
+
+	// Items
+	case *Docs, *Identifier:
+		// nothing to do
+
+	case *Package:
+		if n.Docs != nil {
+			Walk(v, n.Docs)
+		}
+		walkList(v, n.Interfaces)
+		walkList(v, n.Worlds)
+
+	case *World:
+		walkDocs(v, n.Docs, n.Gates)
+		walkList(v, n.ExportItems)
+		walkList(v, n.ImportItems)
+		walkList(v, n.UseItems)
+		walkList(v, n.TypedefItems)
+		walkList(v, n.IncludeItems)
+
+	case *Interface:
+		walkDocs(v, n.Docs, n.Gates)
+		Walk(v, &n.Items)
+
+	case *InterfaceItems:
+		walkList(v, n.UseItems)
+		walkList(v, n.TypedefItems)
+		walkList(v, n.FuncItems)
+
+	case *Use:
+		walkDocs(v, n.Docs, n.Gates)
+		walkUseInterface(v, &n.UseInterface)
+
+	case *UsePath:
+		if n.Namespace != nil {
+			Walk(v, n.Namespace)
+		}
+		if n.Package != nil {
+			Walk(v, n.Package)
+		}
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+
+	case *Gate:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Feature != nil {
+			Walk(v, n.Feature)
+		}
+
+	// Types
+	case *Primitive:
+		// nothing to do
+
+	case *Named:
+		Walk(v, n.Name)
+
+	case *List:
+		walkType(v, n.Elem)
+
+	case *Option:
+		walkType(v, n.Elem)
+
+	case *Result:
+		walkType(v, n.Ok)
+		walkType(v, n.Err)
+
+	case *Tuple:
+		for _, e := range n.Elems {
+			walkType(v, e)
+		}
+
+	case *Handle:
+		if n.Resource != nil {
+			Walk(v, n.Resource)
+		}
+
+	case *Future:
+		walkType(v, n.Elem)
+
+	case *Stream:
+		walkType(v, n.Elem)
+
+	// Type definitions
+	case *TypeDef:
+		// the name of a type definition is reached through its kind
+		walkDocs(v, n.Docs, n.Gates)
+		if n.Kind != nil {
+			Walk(v, n.Kind)
+		}
+
+	case *TypeShape:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkType(v, n.Value)
+
+	case *RecordShape:
+		if n.Identifier != nil {
+			Walk(v, n.Identifier)
+		}
+		walkList(v, n.Fields)
+
+	case *RecordField:
+		walkDocs(v, n.Docs, n.Gates)
+		if n.Identifier != nil {
+			Walk(v, n.Identifier)
+		}
+		walkType(v, n.Type)
+
+	case *VariantShape:
+		if n.Identifier != nil {
+			Walk(v, n.Identifier)
+		}
+		walkList(v, n.Cases)
+
+	case *VariantCase:
+		walkDocs(v, n.Docs, n.Gates)
+		if n.Identifier != nil {
+			Walk(v, n.Identifier)
+		}
+		walkType(v, n.Type)
+
+	case *EnumShape:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkList(v, n.Cases)
+
+	case *EnumCase:
+		walkDocs(v, n.Docs, n.Gates)
+		if n.Identifier != nil {
+			Walk(v, n.Identifier)
+		}
+
+	case *FlagShape:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkList(v, n.Flags)
+
+	case *Flag:
+		walkDocs(v, n.Docs, n.Gates)
+		if n.Identifier != nil {
+			Walk(v, n.Identifier)
+		}
+
+	case *UnionShape:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		for _, c := range n.Cases {
+			walkType(v, c)
+		}
+
+	case *ResourceShape:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Constructor != nil {
+			Walk(v, n.Constructor)
+		}
+		walkList(v, n.Methods)
+		walkList(v, n.StaticFuncs)
+
+	// Functions
+	case *FuncShape:
+		walkDocs(v, n.Docs, n.Gates)
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.Func != nil {
+			Walk(v, n.Func)
+		}
+
+	case *FuncType:
+		if n.ParamList != nil {
+			Walk(v, n.ParamList)
+		}
+		if n.ResultList != nil {
+			Walk(v, n.ResultList)
+		}
+
+	case *ParamList:
+		walkList(v, *n)
+
+	case *ResultList:
+		walkList(v, *n)
+
+	case *NamedType:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		walkType(v, n.Type)
+
+	// World items
+	case *UseShape:
+		walkDocs(v, n.Docs, n.Gates)
+		walkUseInterface(v, &n.UseInterface)
+
+	case *ExportShape:
+		walkDocs(v, n.Docs, n.Gates)
+		walkExtern(v, n.Name, n.Path, n.Func, n.Interface)
+
+	case *ImportShape:
+		walkDocs(v, n.Docs, n.Gates)
+		walkExtern(v, n.Name, n.Path, n.Func, n.Interface)
+
+	case *IncludeShape:
+		walkDocs(v, n.Docs, n.Gates)
+		walkName(v, n.Name, n.Path)
+		for i := range n.With {
+			Walk(v, &n.With[i])
+		}
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+// Inspect calls f for every node of the tree rooted at node, in the order
+// Walk visits them. Returning false from f skips the children of the node
+// it was called with. Once the children of a node have all been inspected,
+// f is called with nil.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(visitFunc(f), node)
+}
+
+// visitFunc lets Inspect hand a plain function to Walk.
+type visitFunc func(Node) bool
+
+func (f visitFunc) Visit(node Node) Visitor {
+	if !f(node) {
+		return nil
+	}
+	return f
+}
+
+func walkDocs(v Visitor, docs *Docs, gates Gates) {
+	if docs != nil {
+		Walk(v, docs)
+	}
+	for _, g := range gates {
+		Walk(v, g)
+	}
+}
+
+func walkType(v Visitor, ty Type) {
+	if ty != nil {
+		Walk(v, ty)
+	}
+}
+
+func walkUseInterface(v Visitor, ui *UseInterface) {
+	if ui.Path != nil {
+		Walk(v, ui.Path)
+	}
+	for i := range ui.Items {
+		Walk(v, &ui.Items[i])
+	}
+}
+
+// walkName walks the path an item refers to, or its name if it has none.
+func walkName(v Visitor, name *Identifier, path *UsePath) {
+	switch {
+	case path != nil:
+		Walk(v, path)
+	case name != nil:
+		Walk(v, name)
+	}
+}
+
+func walkExtern(v Visitor, name *Identifier, path *UsePath, ft *FuncType, ii *InterfaceItems) {
+	walkName(v, name, path)
+	if ft != nil {
+		Walk(v, ft)
+	}
+	if ii != nil {
+		Walk(v, ii)
+	}
+}
+
+func walkList[N Node](v Visitor, list []N) {
+	for _, n := range list {
+		Walk(v, n)
+	}
+}
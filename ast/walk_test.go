@@ -0,0 +1,125 @@
+package ast_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const walkInput = `package local:demo;
+
+interface types {
+  /// A pair of things
+  record pair {
+    @since(version = 0.2.0)
+    items: list<option<tuple<u8, string>>>,
+  }
+
+  resource blob {
+    constructor(init: list<u8>)
+    read: func(n: u32) -> result<_, string>
+  }
+}
+
+world demo {
+  use types.{pair as p}
+  export run: func(p: p) -> own<blob>
+}`
+
+func mustParse(t *testing.T, src string) *ast.AST {
+	t.Helper()
+
+	tree, err := parser.ParseString("walk.wit", src)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return tree
+}
+
+// collector records the nodes it visits and checks that every node is
+// followed by a matching Visit(nil).
+type collector struct {
+	nodes []string
+	depth int
+}
+
+func (c *collector) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		c.depth--
+		return nil
+	}
+	c.depth++
+	c.nodes = append(c.nodes, fmt.Sprintf("%T", node))
+	return c
+}
+
+func TestWalk(t *testing.T) {
+	tree := mustParse(t, walkInput)
+
+	c := new(collector)
+	ast.Walk(c, tree)
+	assert.Equal(t, 0, c.depth)
+	assert.Equal(t, "*ast.AST", c.nodes[0])
+
+	count := map[string]int{}
+	for _, n := range c.nodes {
+		count[n]++
+	}
+	assert.Equal(t, 1, count["*ast.Package"])
+	assert.Equal(t, 1, count["*ast.Interface"])
+	assert.Equal(t, 1, count["*ast.World"])
+	assert.Equal(t, 2, count["*ast.TypeDef"])
+	assert.Equal(t, 1, count["*ast.RecordField"])
+	assert.Equal(t, 1, count["*ast.Docs"])
+	assert.Equal(t, 1, count["*ast.Gate"])
+	assert.Equal(t, 3, count["*ast.FuncShape"]+count["*ast.ExportShape"])
+	assert.Equal(t, 1, count["*ast.Tuple"])
+	assert.Equal(t, 1, count["*ast.Result"])
+	assert.Equal(t, 1, count["*ast.Handle"])
+	assert.Equal(t, 1, count["*ast.UseShape"])
+}
+
+func TestInspectNestedTypes(t *testing.T) {
+	tree := mustParse(t, walkInput)
+
+	var types []string
+	ast.Inspect(tree.Interfaces[0].Items.TypedefItems[0], func(n ast.Node) bool {
+		if ty, ok := n.(ast.Type); ok {
+			types = append(types, ty.TokenLiteral())
+		}
+		return true
+	})
+	assert.Equal(t, []string{"list", "option", "tuple", "u8", "string"}, types)
+
+	// names of parameters, results and use aliases are identifiers too
+	var names []string
+	ast.Inspect(tree.World("demo"), func(n ast.Node) bool {
+		if id, ok := n.(*ast.Identifier); ok {
+			names = append(names, id.Value)
+		}
+		return true
+	})
+	assert.Equal(t, []string{"run", "p", "p", "blob", "types", "pair"}, names)
+}
+
+func TestInspectPrune(t *testing.T) {
+	tree := mustParse(t, walkInput)
+
+	var funcs, types int
+	ast.Inspect(tree, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncShape:
+			funcs++
+			return false
+		case ast.Type:
+			types++
+		}
+		return true
+	})
+	assert.Equal(t, 2, funcs)
+	// the record field and the export, but nothing inside resource functions
+	assert.Equal(t, 5+2, types)
+}
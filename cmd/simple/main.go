@@ -1,100 +1,53 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/jordan-rash/go-wit/ast"
-	"github.com/jordan-rash/go-wit/lexer"
+	"github.com/jordan-rash/go-wit/diagnostic"
 	"github.com/jordan-rash/go-wit/parser"
 )
 
 func main() {
-	done := make(chan *ast.AST, 1)
+	tree := parseWit()
 
-	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*3)
-	defer cancel()
-
-	go parseWit(done)
-
-	var tree *ast.AST
-
-	select {
-	case <-ctxTimeout.Done():
-		panic(fmt.Errorf("time limit"))
-	case tree = <-done:
-		// fmt.Println("got tree")
+	if pkg := tree.Package; pkg != nil {
+		fmt.Println("Package: ", pkg.Namespace+":"+pkg.Name)
+		fmt.Println("Version: ", pkg.Version)
 	}
 
-	for _, s := range tree.Shapes {
-		switch s.TokenLiteral() {
-		case "package":
-			tS, ok := s.(*ast.PackageShape)
-			if !ok {
-				// error
-			}
-			fmt.Println("Package: ", tS.Value)
-			fmt.Println("Version: ", tS.Version)
-		case "interface":
-			tS, ok := s.(*ast.InterfaceShape)
-			if !ok {
-				// error
-			}
-			fmt.Println("Interface: ", tS.Name.Value)
-			for _, c := range tS.Children {
-				switch c.TokenLiteral() {
-				case "type":
-					tC, ok := c.(*ast.TypeStatement)
-					if !ok {
-						// error
-					}
-					fmt.Println("\t", tC.Name.TokenLiteral(), tC.Value.TokenLiteral())
-
-				case "func":
-					tC, ok := c.(*ast.FuncShape)
-					if !ok {
-						// error
-					}
-					fmt.Println("\t", tC.TokenLiteral(), tC.Value.TokenLiteral())
-				case "use":
-					tC, ok := c.(*ast.UseShape)
-					if !ok {
-						// error
-					}
-					fmt.Println("\t", tC.TokenLiteral(), tC.Value.TokenLiteral())
-				default:
-					// error
-				}
-			}
-		case "world":
-			tS, ok := s.(*ast.WorldShape)
-			if !ok {
-				// error
-			}
-			fmt.Println("World: ", tS.Name.Value)
-			for _, c := range tS.Children {
-				switch c.TokenLiteral() {
-				case "export":
-					tC, ok := c.(*ast.ExportShape)
-					if !ok {
-						// error
-					}
-					fmt.Println("\t", tC.Name.TokenLiteral(), tC.Value.TokenLiteral())
-
-				default:
-					// error
-				}
-			}
+	for _, iFace := range tree.Interfaces {
+		fmt.Println("Interface: ", iFace.Name)
+		for _, u := range iFace.Items.UseItems {
+			fmt.Println("\t", u.TokenLiteral(), u.UseInterface.Path)
+		}
+		for _, td := range iFace.Items.TypedefItems {
+			fmt.Println("\t", td.Name.Value, typeLiteral(td.Kind))
+		}
+		for _, f := range iFace.Items.FuncItems {
+			fmt.Println("\t", f.Name.Value, f.Func.TokenLiteral())
+		}
+	}
 
-		default:
-			// error
+	for _, w := range tree.Worlds {
+		fmt.Println("World: ", w.Name)
+		for _, e := range w.ExportItems {
+			fmt.Println("\t", e.TokenLiteral(), e.Name.Value)
 		}
 	}
 }
 
-func parseWit(done chan *ast.AST) {
+// typeLiteral returns the literal of the type a type alias refers to, or
+// the kind of any other type definition.
+func typeLiteral(k ast.TypeDefKind) string {
+	if ts, ok := k.(*ast.TypeShape); ok && ts.Value != nil {
+		return ts.Value.TokenLiteral()
+	}
+	return k.TokenLiteral()
+}
+
+func parseWit() *ast.AST {
 	b, err := os.ReadFile("./pingpong.wit")
 	if err != nil {
 		panic(err)
@@ -102,12 +55,11 @@ func parseWit(done chan *ast.AST) {
 
 	fmt.Printf("Parsing the following file:\n\n```wit\n%s\n```\n\n", string(b))
 
-	p := parser.New(lexer.NewLexer(string(b)))
-	t := p.Parse()
-
-	if p.Errors() != nil {
-		panic(p.Errors())
+	t, err := parser.ParseString("pingpong.wit", string(b))
+	if errs, ok := err.(diagnostic.DiagnosticList); ok {
+		_ = errs.Render(os.Stderr, string(b))
+		os.Exit(1)
 	}
 
-	done <- t
+	return t
 }
@@ -3,13 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"strings"
 	"text/template"
 
 	"github.com/jordan-rash/go-wit/ast"
-	"github.com/jordan-rash/go-wit/lexer"
 	"github.com/jordan-rash/go-wit/parser"
 
 	_ "embed"
@@ -71,110 +69,72 @@ func main() {
 	flag.Parse()
 
 	if file != "" {
-		witfile, err := ioutil.ReadFile(file)
+		t, err := parser.ParseFile(file)
 		if err != nil {
-			fmt.Println(err.Error())
-		}
-
-		p := parser.New(lexer.NewLexer(string(witfile)))
-		t := p.Parse()
-
-		if p.Errors() != nil {
-			fmt.Printf("parser errors: %s", p.Errors().Error())
+			fmt.Printf("parser errors: %s", err.Error())
 			return
 		}
 
 		wf := new(wasifill)
 
-		for _, s := range t.Shapes {
-			switch s.TokenLiteral() {
-			case "package":
-				tS, ok := s.(*ast.PackageShape)
-				if !ok {
-					fmt.Println("package error")
-					return
-				}
+		if pkg := t.Package; pkg != nil {
+			wf.PackageNamespace = pkg.Namespace
+			wf.PackageContract = pkg.Name
+			if pkg.Version != nil {
+				wf.Version = pkg.Version.String()
+			}
+		}
 
-				wf.PackageNamespace = strings.Split(tS.Value, ":")[0]
-				wf.PackageContract = strings.Split(tS.Value, ":")[1]
-				wf.Version = tS.Version
-			case "interface":
-				tS, ok := s.(*ast.InterfaceShape)
-				if !ok {
-					fmt.Println("interface error")
-					return
+		for _, iFace := range t.Interfaces {
+			for _, td := range iFace.Items.TypedefItems {
+				// only type aliases are generated, other kinds are skipped
+				ts, ok := td.Kind.(*ast.TypeShape)
+				switch {
+				case !ok || ts.Value == nil:
+					warnSkipped(iFace.Name, td.Kind.TokenLiteral(), td.Name.Value, "not a type alias")
+					continue
+				case isAsync(ts.Value):
+					warnSkipped(iFace.Name, td.Kind.TokenLiteral(), td.Name.Value, "future and stream types are not supported")
+					continue
 				}
-				for _, c := range tS.Children {
-					switch c.TokenLiteral() {
-					case "type":
-						tC, ok := c.(*ast.TypeStatement)
-						if !ok {
-							fmt.Println("interface type error")
-							return
-						}
-
-						tT := wftype{
-							Interface: tS.Name.Value,
-							Name:      tC.Name.TokenLiteral(),
-							Type:      tC.Value.TokenLiteral(),
-						}
-
-						wf.Types = append(wf.Types, tT)
-					case "func":
-						tC, ok := c.(*ast.FuncShape)
-						if !ok {
-							fmt.Println("interface use error")
-							return
-						}
-
-						tF := wffunc{
-							Interface: tS.Name.Value,
-							Name:      tC.Name.TokenLiteral(),
-							Input:     "",
-							Output:    tC.Value.TokenLiteral(),
-						}
-
-						wf.Funcs = append(wf.Funcs, tF)
-					case "use":
-						_, ok := c.(*ast.UseShape)
-						if !ok {
-							fmt.Println("interface use error")
-							return
-						}
-						// fmt.Println("\t", tC.TokenLiteral(), tC.Value.TokenLiteral())
-					default:
-						fmt.Printf("interface child error: %T\n", c)
-						return
-					}
+
+				tT := wftype{
+					Interface: iFace.Name,
+					Name:      td.Name.Value,
+					Type:      ts.Value.TokenLiteral(),
 				}
 
-			case "world":
-				tS, ok := s.(*ast.WorldShape)
-				if !ok {
-					fmt.Println("world error")
-					return
+				wf.Types = append(wf.Types, tT)
+			}
+
+			for _, f := range iFace.Items.FuncItems {
+				tF := wffunc{
+					Interface: iFace.Name,
+					Name:      f.Name.Value,
+					Input:     "",
 				}
-				for _, c := range tS.Children {
-					switch c.TokenLiteral() {
-					case "export":
-						tC, ok := c.(*ast.ExportShape)
-						if !ok {
-							fmt.Println("interface use error")
-							return
-						}
-						tE := wfexports{
-							Type: "function",
-							Name: tC.Value.TokenLiteral(),
-						}
-						wf.Exports = append(wf.Exports, tE)
+
+				if ft := f.Func; ft.ResultList != nil && len(*ft.ResultList) > 0 {
+					result := (*ft.ResultList)[0].Type
+					if isAsync(result) {
+						warnSkipped(iFace.Name, "func", f.Name.Value, "future and stream types are not supported")
+						continue
 					}
+					tF.Output = result.TokenLiteral()
 				}
 
-			default:
-				fmt.Println("error: invalid root token")
-				return
+				wf.Funcs = append(wf.Funcs, tF)
 			}
+		}
 
+		for _, w := range t.Worlds {
+			for _, e := range w.ExportItems {
+				tE := wfexports{
+					Type: "function",
+					Name: e.Name.Value,
+				}
+				wf.Exports = append(wf.Exports, tE)
+			}
 		}
 
 		err = generateFiles(wf)
@@ -188,6 +148,22 @@ func main() {
 	}
 }
 
+// warnSkipped tells the user that the item kind name of the interface iFace
+// is left out of the generated code, and why.
+func warnSkipped(iFace, kind, name, reason string) {
+	fmt.Fprintf(os.Stderr, "warning: skipping %s %s in interface %s: %s\n", kind, name, iFace, reason)
+}
+
+// isAsync reports whether ty is a future or a stream, which have no
+// counterpart in the generated code.
+func isAsync(ty ast.Type) bool {
+	switch ty.(type) {
+	case *ast.Future, *ast.Stream:
+		return true
+	}
+	return false
+}
+
 func generateFiles(wf *wasifill) error {
 	_, err := os.Stat("gen")
 	if os.IsNotExist(err) {
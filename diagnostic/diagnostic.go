@@ -0,0 +1,159 @@
+// Package diagnostic describes problems found while lexing, parsing or
+// resolving WIT source, and renders them as source excerpts.
+package diagnostic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jordan-rash/go-wit/token"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Info
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Info:
+		return "info"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Note adds context to a diagnostic, optionally pointing at another span.
+type Note struct {
+	Span    token.Span
+	Message string
+}
+
+// Fix is a suggested edit that resolves a diagnostic: the text covered by
+// Span should be replaced with Replacement.
+type Fix struct {
+	Message     string
+	Span        token.Span
+	Replacement string
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Span     token.Span
+	Code     string
+	Message  string
+	Notes    []Note
+	Fixes    []Fix
+}
+
+// Error formats the diagnostic as "file:line:col: severity[code]: message".
+func (d *Diagnostic) Error() string {
+	sb := strings.Builder{}
+
+	if d.Span.IsValid() || d.Span.Start.Filename != "" {
+		sb.WriteString(d.Span.Start.String())
+		sb.WriteString(": ")
+	}
+
+	sb.WriteString(d.Severity.String())
+	if d.Code != "" {
+		sb.WriteString("[" + d.Code + "]")
+	}
+	sb.WriteString(": ")
+	sb.WriteString(d.Message)
+
+	return sb.String()
+}
+
+// DiagnosticList is a list of diagnostics. The zero value is an empty list
+// ready to use.
+type DiagnosticList []*Diagnostic
+
+// Add appends a diagnostic to the list.
+func (l *DiagnosticList) Add(d *Diagnostic) {
+	*l = append(*l, d)
+}
+
+// Addf appends an error diagnostic built from a format string.
+func (l *DiagnosticList) Addf(span token.Span, code string, format string, args ...any) *Diagnostic {
+	d := &Diagnostic{
+		Severity: Error,
+		Span:     span,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	l.Add(d)
+	return d
+}
+
+func (l DiagnosticList) Len() int      { return len(l) }
+func (l DiagnosticList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+
+func (l DiagnosticList) Less(i, j int) bool {
+	a, b := l[i].Span.Start, l[j].Span.Start
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	if a.Offset != b.Offset {
+		return a.Offset < b.Offset
+	}
+	return l[i].Severity < l[j].Severity
+}
+
+// Sort sorts the list by file name, then source position, then severity.
+func (l DiagnosticList) Sort() {
+	sort.Stable(l)
+}
+
+// Filter returns the diagnostics for which keep returns true.
+func (l DiagnosticList) Filter(keep func(*Diagnostic) bool) DiagnosticList {
+	var out DiagnosticList
+	for _, d := range l {
+		if keep(d) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// HasErrors reports whether the list contains at least one diagnostic with
+// Error severity.
+func (l DiagnosticList) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Error implements the error interface, listing one diagnostic per line.
+func (l DiagnosticList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+
+	lines := make([]string, len(l))
+	for i, d := range l {
+		lines[i] = d.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Err returns an error equivalent to the list, or nil if the list is empty.
+func (l DiagnosticList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
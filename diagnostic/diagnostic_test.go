@@ -0,0 +1,68 @@
+package diagnostic_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jordan-rash/go-wit/diagnostic"
+	"github.com/jordan-rash/go-wit/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func pos(line, column, offset int) token.Position {
+	return token.Position{Filename: "derp.wit", Line: line, Column: column, Offset: offset}
+}
+
+func TestDiagnosticListError(t *testing.T) {
+	var l diagnostic.DiagnosticList
+	assert.NoError(t, l.Err())
+	assert.False(t, l.HasErrors())
+
+	l.Addf(token.Span{Start: pos(2, 5, 10)}, "P0001", "second")
+	l.Add(&diagnostic.Diagnostic{Severity: diagnostic.Warning, Span: token.Span{Start: pos(1, 1, 0)}, Message: "first"})
+
+	assert.Error(t, l.Err())
+	assert.True(t, l.HasErrors())
+	assert.Equal(t, 2, l.Len())
+
+	l.Sort()
+	assert.Equal(t, "derp.wit:1:1: warning: first\nderp.wit:2:5: error[P0001]: second", l.Error())
+
+	warnings := l.Filter(func(d *diagnostic.Diagnostic) bool { return d.Severity == diagnostic.Warning })
+	assert.Len(t, warnings, 1)
+	assert.False(t, warnings.HasErrors())
+}
+
+func TestDiagnosticRender(t *testing.T) {
+	src := "package wasi:derp\n\ninterface {\n}\n"
+
+	d := &diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Span:     token.Span{Start: pos(3, 11, 29), End: pos(3, 12, 30)},
+		Code:     "P0002",
+		Message:  "expected IDENT, got {",
+		Notes:    []diagnostic.Note{{Message: "interfaces must be named"}},
+		Fixes:    []diagnostic.Fix{{Message: "name the interface", Replacement: "foo"}},
+	}
+
+	sb := strings.Builder{}
+	assert.NoError(t, d.Render(&sb, src))
+
+	expected := `error[P0002]: expected IDENT, got {
+ --> derp.wit:3:11
+  |
+3 | interface {
+  |           ^
+  = note: interfaces must be named
+  = help: name the interface: ` + "`foo`" + `
+`
+	assert.Equal(t, expected, sb.String())
+}
+
+func TestDiagnosticRenderWithoutSource(t *testing.T) {
+	d := &diagnostic.Diagnostic{Severity: diagnostic.Warning, Message: "no position"}
+
+	sb := strings.Builder{}
+	assert.NoError(t, d.Render(&sb, ""))
+	assert.Equal(t, "warning: no position\n", sb.String())
+}
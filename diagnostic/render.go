@@ -0,0 +1,134 @@
+package diagnostic
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Render writes the diagnostic in the style of rustc, quoting the offending
+// line of src and underlining the span with carets:
+//
+//	error[P0001]: expected IDENT, got {
+//	 --> derp.wit:3:11
+//	  |
+//	3 | interface {
+//	  |           ^
+//	  = note: interfaces must be named
+//
+// src must be the text of the file the diagnostic's span refers to. If the
+// span does not fall within src only the header is written.
+func (d *Diagnostic) Render(w io.Writer, src string) error {
+	header := d.Severity.String()
+	if d.Code != "" {
+		header += "[" + d.Code + "]"
+	}
+
+	if _, err := fmt.Fprintf(w, "%s: %s\n", header, d.Message); err != nil {
+		return err
+	}
+
+	line, ok := sourceLine(src, d.Span.Start.Offset, d.Span.Start.Column)
+	if !d.Span.IsValid() || !ok {
+		return d.renderNotes(w, "")
+	}
+
+	num := strconv.Itoa(d.Span.Start.Line)
+	gutter := strings.Repeat(" ", len(num))
+
+	width := 1
+	if d.Span.End.Line == d.Span.Start.Line && d.Span.End.Column > d.Span.Start.Column {
+		width = d.Span.End.Column - d.Span.Start.Column
+		if end := d.Span.Start.Column - 1 + width; end <= len(line) {
+			width = utf8.RuneCountInString(line[d.Span.Start.Column-1 : end])
+		}
+	}
+
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "%s--> %s\n", gutter, d.Span.Start)
+	fmt.Fprintf(&sb, "%s |\n", gutter)
+	fmt.Fprintf(&sb, "%s | %s\n", num, line)
+	fmt.Fprintf(&sb, "%s | %s%s\n", gutter, padding(line, d.Span.Start.Column-1), strings.Repeat("^", width))
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+
+	return d.renderNotes(w, gutter)
+}
+
+func (d *Diagnostic) renderNotes(w io.Writer, gutter string) error {
+	sb := strings.Builder{}
+
+	for _, n := range d.Notes {
+		fmt.Fprintf(&sb, "%s = note: %s", gutter, n.Message)
+		if n.Span.IsValid() {
+			fmt.Fprintf(&sb, " (%s)", n.Span.Start)
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, f := range d.Fixes {
+		fmt.Fprintf(&sb, "%s = help: %s", gutter, f.Message)
+		if f.Replacement != "" {
+			fmt.Fprintf(&sb, ": `%s`", f.Replacement)
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Render writes every diagnostic in the list, separated by blank lines. All
+// diagnostics are assumed to refer to src.
+func (l DiagnosticList) Render(w io.Writer, src string) error {
+	for i, d := range l {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := d.Render(w, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourceLine returns the line of src containing offset, given the 1-based
+// column of offset within that line.
+func sourceLine(src string, offset, column int) (string, bool) {
+	start := offset - (column - 1)
+	if column < 1 || start < 0 || offset > len(src) {
+		return "", false
+	}
+
+	end := strings.IndexByte(src[start:], '\n')
+	if end < 0 {
+		end = len(src) - start
+	}
+
+	return strings.TrimRight(src[start:start+end], "\r"), true
+}
+
+// padding returns whitespace as wide as the first n bytes of line, keeping
+// tabs so the carets line up with the quoted source. Multi-byte characters
+// count as a single column.
+func padding(line string, n int) string {
+	if n > len(line) {
+		line += strings.Repeat(" ", n-len(line))
+	}
+
+	sb := strings.Builder{}
+	for _, r := range line[:n] {
+		if r == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+	return sb.String()
+}
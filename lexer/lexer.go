@@ -1,30 +1,242 @@
 package lexer
 
 import (
-	"strconv"
+	"io"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
+	"github.com/jordan-rash/go-wit/diagnostic"
 	"github.com/jordan-rash/go-wit/token"
 )
 
+// Diagnostic codes reported by the lexer
+const (
+	ERROR_INVALID_IDENTIFIER = "L0001"
+	ERROR_NON_ASCII          = "L0002"
+	ERROR_READ               = "L0003"
+)
+
 type Lexer struct {
-	input        string
+	filename     string
+	input        *source
 	position     int
 	readPosition int
-	ch           byte
+	ch           rune
+
+	// location of position, tracked as the input is read
+	line        int
+	lineStart   int
+	utf16Column int
+
+	errors diagnostic.DiagnosticList
 }
 
 func NewLexer(input string) *Lexer {
-	l := new(Lexer)
-	l.input = input
+	return NewFileLexer("", input)
+}
+
+// NewFileLexer returns a lexer whose token positions report filename as
+// their source.
+func NewFileLexer(filename, input string) *Lexer {
+	return newLexer(filename, newStringSource(input))
+}
+
+// NewReader returns a lexer reading its input from r as tokens are
+// requested, so the input is never held in memory all at once. Token
+// positions report filename as their source.
+func NewReader(r io.Reader, filename string) *Lexer {
+	return newLexer(filename, newReaderSource(r))
+}
+
+func newLexer(filename string, input *source) *Lexer {
+	l := &Lexer{filename: filename, input: input, line: 1, utf16Column: 1}
 	l.readChar()
+	if l.ch == '\uFEFF' {
+		l.readChar() // byte order mark
+	}
 	return l
 }
 
+// Tokenize returns every token read from r up to, but not including, the
+// end of the input, along with diagnostics for malformed tokens.
+func Tokenize(r io.Reader, filename string) ([]token.Token, diagnostic.DiagnosticList) {
+	l := NewReader(r, filename)
+	return l.Tokens(), l.Errors()
+}
+
+// Tokens returns the remaining tokens up to, but not including, the end of
+// the input.
+func (l *Lexer) Tokens() []token.Token {
+	var toks []token.Token
+	for {
+		tok := l.NextToken()
+		if tok.Type == token.END_OF_FILE {
+			return toks
+		}
+		toks = append(toks, tok)
+	}
+}
+
 func (l *Lexer) NextToken() token.Token {
+	l.input.discard(l.position)
 	l.skipWhiteSpace()
 
+	for l.ch == '/' && (l.peek() == '/' || l.peek() == '*') {
+		start := l.pos()
+
+		tok := l.readComment()
+		if tok.Type != "" {
+			tok.Pos = start
+			tok.End = l.pos()
+			return tok
+		}
+
+		l.skipWhiteSpace()
+	}
+
+	start := l.pos()
+	tok := l.nextToken()
+	tok.Pos = start
+	tok.End = l.pos()
+
+	if l.input.err != nil {
+		l.errors.Addf(tok.Span(), ERROR_READ, "reading %s: %v", l.filename, l.input.err)
+		l.input.err = nil
+	}
+
+	switch {
+	case !isASCII(tok.Literal):
+		l.errors.Addf(tok.Span(), ERROR_NON_ASCII, "identifiers must be ASCII: %q", tok.Literal)
+	case tok.Type == token.IDENTIFIER:
+		if reason := validateIdentifier(tok.Literal); reason != "" {
+			l.errors.Addf(tok.Span(), ERROR_INVALID_IDENTIFIER, "invalid identifier %q: %s", tok.Literal, reason)
+		}
+	}
+
+	return tok
+}
+
+// Errors returns the diagnostics for malformed tokens read so far.
+func (l *Lexer) Errors() diagnostic.DiagnosticList {
+	return l.errors
+}
+
+// validateIdentifier checks id against the WIT word rules: lowercase words
+// or uppercase acronyms, each starting with a letter, joined by single
+// hyphens. It returns why id is invalid, or "" if it is valid.
+func validateIdentifier(id string) string {
+	for _, word := range strings.Split(id, "-") {
+		if word == "" {
+			return "words must be separated by single hyphens"
+		}
+
+		if !isLetter(rune(word[0])) {
+			return "words must start with a letter"
+		}
+
+		lower, upper := false, false
+		for i := 0; i < len(word); i++ {
+			switch c := word[i]; {
+			case 'a' <= c && c <= 'z':
+				lower = true
+			case 'A' <= c && c <= 'Z':
+				upper = true
+			case '0' <= c && c <= '9':
+			default:
+				return "identifiers may only contain letters, digits and hyphens"
+			}
+		}
+
+		if lower && upper {
+			return "words must be all lowercase or all uppercase"
+		}
+	}
+
+	return ""
+}
+
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
+}
+
+func isDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(lit string) bool {
+	for i := 0; i < len(lit); i++ {
+		if !isDigit(rune(lit[i])) {
+			return false
+		}
+	}
+	return lit != ""
+}
+
+// readComment consumes a line or block comment. Documentation comments
+// (`///` and `/** */`) are returned as COMMENT_DOCUMENTATION tokens, other
+// comments return a token without a type. Block comments may nest.
+func (l *Lexer) readComment() token.Token {
+	pos := l.position
+
+	if l.peek() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+
+		lit := l.input.slice(pos, l.position)
+		if strings.HasPrefix(lit, token.COMMENT_DOCUMENTATION) && !strings.HasPrefix(lit, "////") {
+			return token.Token{Type: token.COMMENT_DOCUMENTATION, Literal: strings.TrimRight(lit, "\r")}
+		}
+		return token.Token{}
+	}
+
+	l.readChar() // '/'
+	l.readChar() // '*'
+
+	depth := 1
+	for depth > 0 {
+		switch {
+		case l.ch == 0:
+			return token.Token{Type: token.ILLEGAL, Literal: l.input.slice(pos, l.position)}
+		case l.ch == '/' && l.peek() == '*':
+			l.readChar()
+			depth++
+		case l.ch == '*' && l.peek() == '/':
+			l.readChar()
+			depth--
+		}
+		l.readChar()
+	}
+
+	lit := l.input.slice(pos, l.position)
+	if strings.HasPrefix(lit, "/**") && !strings.HasPrefix(lit, "/***") && lit != "/**/" {
+		return token.Token{Type: token.COMMENT_DOCUMENTATION, Literal: lit}
+	}
+	return token.Token{}
+}
+
+// pos returns the position of the current character.
+func (l *Lexer) pos() token.Position {
+	return token.Position{
+		Filename:    l.filename,
+		Offset:      l.position,
+		Line:        l.line,
+		Column:      l.position - l.lineStart + 1,
+		UTF16Column: l.utf16Column,
+	}
+}
+
+func (l *Lexer) nextToken() token.Token {
 	switch l.ch {
 	case '@':
 		return token.Token{Type: token.OP_AT, Literal: string(l.readChar())}
@@ -47,6 +259,11 @@ func (l *Lexer) NextToken() token.Token {
 	case '=':
 		return token.Token{Type: token.OP_EQUAL, Literal: string(l.readChar())}
 	case '%':
+		p := l.peek()
+		if isLetter(p) || isDigit(p) {
+			l.readChar()
+			return token.Token{Type: token.IDENTIFIER, Literal: l.readIdentifier(), Explicit: true}
+		}
 		return token.Token{Type: token.OP_EXPLICIT_ID, Literal: string(l.readChar())}
 	case '-':
 		p := l.peek()
@@ -71,152 +288,111 @@ func (l *Lexer) NextToken() token.Token {
 		return token.Token{Type: token.OP_UNDERSCORE, Literal: string(x)}
 	case 0:
 		return token.Token{Type: token.END_OF_FILE, Literal: string("EOF")}
-	case 'u':
-		switch l.peekNum() {
-		case 8:
-			return token.Token{Type: token.KEYWORD_U8, Literal: "u" + l.readNumber()}
-		case 16:
-			return token.Token{Type: token.KEYWORD_U16, Literal: "u" + l.readNumber()}
-		case 32:
-			return token.Token{Type: token.KEYWORD_U32, Literal: "u" + l.readNumber()}
-		case 64:
-			return token.Token{Type: token.KEYWORD_U64, Literal: "u" + l.readNumber()}
-		}
-	case 's':
-		switch l.peekNum() {
-		case 8:
-			return token.Token{Type: token.KEYWORD_S8, Literal: "s" + l.readNumber()}
-		case 16:
-			return token.Token{Type: token.KEYWORD_S16, Literal: "s" + l.readNumber()}
-		case 32:
-			return token.Token{Type: token.KEYWORD_S32, Literal: "s" + l.readNumber()}
-		case 64:
-			return token.Token{Type: token.KEYWORD_S64, Literal: "s" + l.readNumber()}
-		}
-	case 'f':
-		if l.peekString() == "float" {
-			l.readString()
-			switch l.peekNum() {
-			case 32:
-				return token.Token{Type: token.KEYWORD_FLOAT32, Literal: "float" + l.readNumber()}
-			case 64:
-				return token.Token{Type: token.KEYWORD_FLOAT64, Literal: "float" + l.readNumber()}
-			}
+	}
+
+	if isDigit(l.ch) {
+		if lit, ok := l.readVersion(); ok {
+			return token.Token{Type: token.VERSION, Literal: lit}
 		}
 	}
 
-	lit := l.readIdentifier()
-	i, err := strconv.ParseInt(lit, 10, 0)
-	if err == nil {
-		return token.Token{Type: token.INT, Literal: strconv.FormatInt(i, 10)}
+	if isLetter(l.ch) || isDigit(l.ch) || l.ch >= utf8.RuneSelf {
+		lit := l.readIdentifier()
+		if isNumber(lit) {
+			return token.Token{Type: token.INT, Literal: lit}
+		}
+
+		return token.Token{Type: token.LookupIdentifier(lit), Literal: lit}
 	}
 
-	return token.Token{Type: token.LookupIdentifier(lit), Literal: lit}
+	return token.Token{Type: token.ILLEGAL, Literal: string(l.readChar())}
 }
 
-func (l *Lexer) peekNum() int {
-	origPos := l.position
+// readVersion consumes a semver such as `0.2.0-rc.1+build.5`. Nothing is
+// consumed unless the run of version characters contains a period, numbers
+// without one are left to readIdentifier.
+func (l *Lexer) readVersion() (string, bool) {
+	end := l.position
+	for {
+		ch, ok := l.input.byteAt(end)
+		if !ok || !isVersionChar(ch) {
+			break
+		}
+		end++
+	}
 
-	sb := strings.Builder{}
-	sb.WriteByte(l.ch)
+	// a trailing period belongs to what follows, as in `@0.2.0.{name}`
+	for end > l.position {
+		if ch, _ := l.input.byteAt(end - 1); ch != '.' && ch != '+' {
+			break
+		}
+		end--
+	}
 
-	for unicode.IsDigit(rune(l.peek())) {
-		b := l.peek()
-		sb.WriteByte(b)
-		l.readPosition++
+	lit := l.input.slice(l.position, end)
+	if !strings.Contains(lit, ".") {
+		return "", false
 	}
 
-	i, _ := strconv.ParseInt(sb.String(), 10, 0)
+	for l.position < end {
+		l.readChar()
+	}
 
-	l.position = origPos
-	return int(i)
+	return lit, true
 }
 
-func (l *Lexer) peekString() string {
-	origPos := l.position
-
-	sb := strings.Builder{}
-	sb.WriteByte(l.ch)
-
-	for unicode.IsLetter(rune(l.peek())) {
-		b := l.peek()
-		sb.WriteByte(b)
-		l.readPosition++
-	}
-
-	l.position = origPos
-	return sb.String()
+func isVersionChar(ch byte) bool {
+	return ch == '.' || ch == '-' || ch == '+' ||
+		'0' <= ch && ch <= '9' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
 }
 
-func (l *Lexer) readChar() byte {
-	var ret byte
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
-		ret = 0
-	} else {
-		ret = l.ch
-		l.ch = l.input[l.readPosition]
+// readChar advances to the next rune of the input and returns the current
+// one. At the end of the input ch is 0.
+func (l *Lexer) readChar() rune {
+	ret := l.ch
+
+	if l.readPosition > l.position {
+		switch {
+		case ret == '\n':
+			l.line++
+			l.lineStart = l.readPosition
+			l.utf16Column = 1
+		case ret > 0xFFFF:
+			l.utf16Column += 2 // surrogate pair
+		default:
+			l.utf16Column++
+		}
 	}
 
 	l.position = l.readPosition
-	l.readPosition++
 
-	return ret
-}
-
-func (l *Lexer) readNumber() string {
-	pos := l.position
-	for unicode.IsDigit(rune(l.ch)) {
-		l.readChar()
-	}
-	return l.input[pos:l.position]
-}
+	r, w := l.input.runeAt(l.readPosition)
+	l.ch = r
+	l.readPosition += w
 
-func (l *Lexer) peek() byte {
-	if l.readPosition >= len(l.input) {
-		return 0
-	}
-	return l.input[l.readPosition]
+	return ret
 }
 
-func (l *Lexer) readString() string {
-	pos := l.position
-	for unicode.IsLetter(rune(l.ch)) {
-		l.readChar()
-	}
-	return l.input[pos:l.position]
+func (l *Lexer) peek() rune {
+	r, _ := l.input.runeAt(l.readPosition)
+	return r
 }
 
 func (l *Lexer) skipWhiteSpace() {
-	for unicode.IsSpace(rune(l.ch)) {
+	for unicode.IsSpace(l.ch) {
 		l.readChar()
 	}
 }
 
-// kabab case
-func (l *Lexer) peekIdentifier() string {
-	origPos := l.position
-
-	sb := strings.Builder{}
-	sb.WriteByte(l.ch)
-
-	for l.peek() == '-' || unicode.IsDigit(rune(l.peek())) || unicode.IsLetter(rune(l.peek())) {
-		b := l.peek()
-		sb.WriteByte(b)
-		l.readPosition++
-	}
-
-	l.position = origPos
-	return sb.String()
-}
-
+// readIdentifier reads a whole word of letters, digits and hyphens, stopping
+// before a `->`. Keywords are told apart from identifiers afterwards, and
+// malformed words, including ones with non-ASCII characters, are read whole
+// so they can be reported as one token.
 func (l *Lexer) readIdentifier() string {
 	pos := l.position
-
-	for l.peek() == '-' || unicode.IsDigit(rune(l.peek())) || unicode.IsLetter(rune(l.peek())) {
+	for isLetter(l.ch) || isDigit(l.ch) || l.ch == '-' && l.peek() != '>' ||
+		l.ch >= utf8.RuneSelf && !unicode.IsSpace(l.ch) {
 		l.readChar()
 	}
-
-	l.readChar()
-	return l.input[pos:l.position]
+	return l.input.slice(pos, l.position)
 }
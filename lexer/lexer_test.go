@@ -1,7 +1,11 @@
 package lexer_test
 
 import (
+	"errors"
+	"os"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/jordan-rash/go-wit/lexer"
 	"github.com/jordan-rash/go-wit/token"
@@ -153,3 +157,304 @@ func TestKebabIdents(t *testing.T) {
 
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "package wasi:derp\n\ninterface foo {\n  bar: func() -> u16\n}"
+
+	l := lexer.NewFileLexer("derp.wit", input)
+
+	tests := []struct {
+		expectedLiteral string
+		line, column    int
+		offset          int
+	}{
+		{"package", 1, 1, 0},
+		{"wasi", 1, 9, 8},
+		{":", 1, 13, 12},
+		{"derp", 1, 14, 13},
+		{"interface", 3, 1, 19},
+		{"foo", 3, 11, 29},
+		{"{", 3, 15, 33},
+		{"bar", 4, 3, 37},
+		{":", 4, 6, 40},
+		{"func", 4, 8, 42},
+		{"(", 4, 12, 46},
+		{")", 4, 13, 47},
+		{"->", 4, 15, 49},
+		{"u16", 4, 18, 52},
+		{"}", 5, 1, 56},
+		{"EOF", 5, 2, 57},
+	}
+
+	for i, tt := range tests {
+		nTok := l.NextToken()
+
+		assert.Equal(t, tt.expectedLiteral, nTok.Literal, i)
+		assert.Equal(t, "derp.wit", nTok.Pos.Filename, i)
+		assert.Equal(t, tt.line, nTok.Pos.Line, i)
+		assert.Equal(t, tt.column, nTok.Pos.Column, i)
+		assert.Equal(t, tt.offset, nTok.Pos.Offset, i)
+		if nTok.Type != token.END_OF_FILE {
+			assert.Equal(t, tt.offset+len(tt.expectedLiteral), nTok.End.Offset, i)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// line comment
+/* block /* nested */ comment */
+/// doc comment
+package wasi:derp // trailing
+//// not a doc comment
+/** block doc */
+/**/
+/***/
+interface foo {}
+/* unterminated`
+
+	l := lexer.NewLexer(input)
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.COMMENT_DOCUMENTATION, "/// doc comment"},
+		{token.KEYWORD_PACKAGE, "package"},
+		{token.IDENTIFIER, "wasi"},
+		{token.OP_COLON, ":"},
+		{token.IDENTIFIER, "derp"},
+		{token.COMMENT_DOCUMENTATION, "/** block doc */"},
+		{token.KEYWORD_INTERFACE, "interface"},
+		{token.IDENTIFIER, "foo"},
+		{token.OP_BRACKET_CURLY_LEFT, "{"},
+		{token.OP_BRACKET_CURLY_RIGHT, "}"},
+		{token.ILLEGAL, "/* unterminated"},
+		{token.END_OF_FILE, "EOF"},
+	}
+
+	for i, tt := range tests {
+		nTok := l.NextToken()
+
+		assert.Equal(t, tt.expectedType, nTok.Type, i)
+		assert.Equal(t, tt.expectedLiteral, nTok.Literal, i)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	input := "@0.2.0-rc-2023-11-10+build.5 @1.0.0.{ 42"
+
+	l := lexer.NewLexer(input)
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.OP_AT, "@"},
+		{token.VERSION, "0.2.0-rc-2023-11-10+build.5"},
+		{token.OP_AT, "@"},
+		{token.VERSION, "1.0.0"},
+		{token.OP_PERIOD, "."},
+		{token.OP_BRACKET_CURLY_LEFT, "{"},
+		{token.INT, "42"},
+		{token.END_OF_FILE, "EOF"},
+	}
+
+	for i, tt := range tests {
+		nTok := l.NextToken()
+
+		assert.Equal(t, tt.expectedType, nTok.Type, i)
+		assert.Equal(t, tt.expectedLiteral, nTok.Literal, i)
+	}
+}
+
+func TestExplicitIdents(t *testing.T) {
+	input := "%record %type %foo-bar % record"
+
+	l := lexer.NewLexer(input)
+
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedExplicit bool
+	}{
+		{token.IDENTIFIER, "record", true},
+		{token.IDENTIFIER, "type", true},
+		{token.IDENTIFIER, "foo-bar", true},
+		{token.OP_EXPLICIT_ID, "%", false},
+		{token.KEYWORD_RECORD, "record", false},
+		{token.END_OF_FILE, "EOF", false},
+	}
+
+	for i, tt := range tests {
+		nTok := l.NextToken()
+
+		assert.Equal(t, tt.expectedType, nTok.Type, i)
+		assert.Equal(t, tt.expectedLiteral, nTok.Literal, i)
+		assert.Equal(t, tt.expectedExplicit, nTok.Explicit, i)
+	}
+
+	assert.Empty(t, l.Errors())
+}
+
+func TestInvalidIdents(t *testing.T) {
+	tests := []struct {
+		input string
+		valid bool
+	}{
+		{"foo", true},
+		{"foo-bar-baz", true},
+		{"http-URL", true},
+		{"TCP", true},
+		{"a1-b2", true},
+		{"foo--bar", false},
+		{"foo-", false},
+		{"9abc", false},
+		{"foo-9abc", false},
+		{"fooBar", false},
+		{"%1-2", false},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewFileLexer("derp.wit", tt.input)
+		tok := l.NextToken()
+		assert.Equal(t, token.TokenType(token.IDENTIFIER), tok.Type, tt.input)
+
+		if tt.valid {
+			assert.Empty(t, l.Errors(), tt.input)
+			continue
+		}
+
+		if assert.Len(t, l.Errors(), 1, tt.input) {
+			d := l.Errors()[0]
+			assert.Equal(t, lexer.ERROR_INVALID_IDENTIFIER, d.Code)
+			assert.Equal(t, tok.Span(), d.Span)
+		}
+	}
+}
+
+func TestKeywordPrefixedIdents(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType token.TokenType
+	}{
+		{"u8", token.KEYWORD_U8},
+		{"u8-array", token.IDENTIFIER},
+		{"u80", token.IDENTIFIER},
+		{"u", token.IDENTIFIER},
+		{"use", token.KEYWORD_USE},
+		{"user", token.IDENTIFIER},
+		{"s3-bucket", token.IDENTIFIER},
+		{"s32", token.KEYWORD_S32},
+		{"s32x", token.IDENTIFIER},
+		{"s", token.IDENTIFIER},
+		{"static", token.KEYWORD_STATIC},
+		{"float32", token.KEYWORD_FLOAT32},
+		{"float32-list", token.IDENTIFIER},
+		{"float", token.IDENTIFIER},
+		{"floating", token.IDENTIFIER},
+		{"f", token.IDENTIFIER},
+		{"func", token.KEYWORD_FUNC},
+		{"funcs", token.IDENTIFIER},
+		{"list-of", token.IDENTIFIER},
+		{"type-id", token.IDENTIFIER},
+		{"record", token.KEYWORD_RECORD},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewLexer(tt.input + " x")
+
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type, tt.input)
+		assert.Equal(t, tt.input, tok.Literal, tt.input)
+
+		// the rest of the input is left intact
+		next := l.NextToken()
+		assert.Equal(t, token.TokenType(token.IDENTIFIER), next.Type, tt.input)
+		assert.Equal(t, "x", next.Literal, tt.input)
+		assert.Equal(t, len(tt.input)+1, next.Pos.Offset, tt.input)
+
+		assert.Empty(t, l.Errors(), tt.input)
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "/// Grüße 👋\n// ✓ plain\nrecord /* ünïcode */ point { café: u8 }"
+
+	l := lexer.NewFileLexer("derp.wit", input)
+
+	tests := []struct {
+		expectedType        token.TokenType
+		expectedLiteral     string
+		expectedColumn      int
+		expectedUTF16Column int
+	}{
+		{token.COMMENT_DOCUMENTATION, "/// Grüße 👋", 1, 1},
+		{token.KEYWORD_RECORD, "record", 1, 1},
+		{token.IDENTIFIER, "point", 24, 22},
+		{token.OP_BRACKET_CURLY_LEFT, "{", 30, 28},
+		{token.IDENTIFIER, "café", 32, 30},
+		{token.OP_COLON, ":", 37, 34},
+		{token.KEYWORD_U8, "u8", 39, 36},
+		{token.OP_BRACKET_CURLY_RIGHT, "}", 42, 39},
+		{token.END_OF_FILE, "EOF", 43, 40},
+	}
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equal(t, tt.expectedType, tok.Type, i)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal, i)
+		assert.Equal(t, tt.expectedColumn, tok.Pos.Column, i)
+		assert.Equal(t, tt.expectedUTF16Column, tok.Pos.UTF16Column, i)
+	}
+
+	if assert.Len(t, l.Errors(), 1) {
+		d := l.Errors()[0]
+		assert.Equal(t, lexer.ERROR_NON_ASCII, d.Code)
+		assert.Equal(t, "derp.wit:3:32", d.Span.Start.String())
+		assert.Contains(t, d.Message, "identifiers must be ASCII")
+	}
+
+	// surrogate pairs take two UTF-16 code units
+	l = lexer.NewLexer("/* 👋 */ foo")
+	tok := l.NextToken()
+	assert.Equal(t, 12, tok.Pos.Column)
+	assert.Equal(t, 10, tok.Pos.UTF16Column)
+}
+
+func TestReader(t *testing.T) {
+	b, err := os.ReadFile("../cmd/simple/core.wit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// large enough for the reader to drop input it has moved past
+	input := strings.Repeat(string(b)+"\n", 4)
+
+	want := lexer.NewFileLexer("core.wit", input).Tokens()
+	got := lexer.NewReader(iotest.OneByteReader(strings.NewReader(input)), "core.wit").Tokens()
+
+	assert.NotEmpty(t, want)
+	assert.Equal(t, want, got)
+}
+
+func TestTokenize(t *testing.T) {
+	toks, errs := lexer.Tokenize(strings.NewReader("record point {\n  x: u32,\n}"), "derp.wit")
+
+	assert.Empty(t, errs)
+	if assert.Len(t, toks, 8) {
+		assert.EqualValues(t, token.KEYWORD_RECORD, toks[0].Type)
+		assert.Equal(t, "derp.wit:1:1", toks[0].Span().Start.String())
+		assert.Equal(t, "derp.wit:1:7", toks[0].Span().End.String())
+		assert.EqualValues(t, token.KEYWORD_U32, toks[5].Type)
+		assert.Equal(t, "derp.wit:2:6", toks[5].Span().Start.String())
+		assert.Equal(t, "derp.wit:2:9", toks[5].Span().End.String())
+		assert.EqualValues(t, token.OP_BRACKET_CURLY_RIGHT, toks[7].Type)
+	}
+
+	_, errs = lexer.Tokenize(iotest.ErrReader(errors.New("derp")), "derp.wit")
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, lexer.ERROR_READ, errs[0].Code)
+		assert.Contains(t, errs[0].Message, "derp")
+	}
+}
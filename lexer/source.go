@@ -0,0 +1,94 @@
+package lexer
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// chunkSize is how much is read from the underlying reader at a time.
+const chunkSize = 4096
+
+// source is a window onto the input. Bytes are read from r on demand and
+// dropped once the lexer has moved past them, so a file never has to be held
+// in memory all at once. Offsets are absolute from the start of the input.
+type source struct {
+	r    io.Reader
+	err  error  // first error returned by r other than io.EOF
+	buf  []byte // input from offset base onwards
+	base int
+	eof  bool
+}
+
+func newStringSource(input string) *source {
+	return &source{buf: []byte(input), eof: true}
+}
+
+func newReaderSource(r io.Reader) *source {
+	return &source{r: r}
+}
+
+// fill reads from r until the byte at offset is buffered or the input is
+// exhausted. It reports whether offset is within the input.
+func (s *source) fill(offset int) bool {
+	for offset-s.base >= len(s.buf) {
+		if s.eof {
+			return false
+		}
+
+		if cap(s.buf)-len(s.buf) < chunkSize {
+			buf := make([]byte, len(s.buf), 2*cap(s.buf)+chunkSize)
+			copy(buf, s.buf)
+			s.buf = buf
+		}
+
+		n, err := s.r.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf = s.buf[:len(s.buf)+n]
+
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			s.eof = true
+		}
+	}
+
+	return true
+}
+
+// byteAt returns the byte at offset, or false past the end of the input.
+func (s *source) byteAt(offset int) (byte, bool) {
+	if !s.fill(offset) {
+		return 0, false
+	}
+	return s.buf[offset-s.base], true
+}
+
+// runeAt decodes the rune starting at offset. Invalid UTF-8 decodes to
+// utf8.RuneError, one byte at a time. The width is 0 past the end of the
+// input.
+func (s *source) runeAt(offset int) (rune, int) {
+	b, ok := s.byteAt(offset)
+	switch {
+	case !ok:
+		return 0, 0
+	case b < utf8.RuneSelf:
+		return rune(b), 1
+	}
+
+	s.fill(offset + utf8.UTFMax - 1)
+	return utf8.DecodeRune(s.buf[offset-s.base:])
+}
+
+// slice returns the input between the offsets start and end, which must
+// already have been read.
+func (s *source) slice(start, end int) string {
+	return string(s.buf[start-s.base : end-s.base])
+}
+
+// discard drops the input before offset, which will not be looked at again.
+func (s *source) discard(offset int) {
+	if n := offset - s.base; n > chunkSize && n <= len(s.buf) {
+		s.buf = s.buf[n:]
+		s.base = offset
+	}
+}
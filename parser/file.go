@@ -0,0 +1,215 @@
+package parser
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/diagnostic"
+	"github.com/jordan-rash/go-wit/lexer"
+	"github.com/jordan-rash/go-wit/token"
+)
+
+// ParseString parses the WIT source src. Positions and diagnostics report
+// name as the source file. If src has syntax errors the returned error is a
+// diagnostic.DiagnosticList and the tree holds everything that could be
+// parsed.
+func ParseString(name, src string) (*ast.AST, error) {
+	return parse(lexer.NewFileLexer(name, src), name)
+}
+
+// ParseFile reads and parses the WIT file at path. Errors are reported as
+// for ParseString, or as the error from opening the file.
+func ParseFile(path string) (*ast.AST, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parse(lexer.NewReader(f, path), path)
+}
+
+// ParseFS parses every .wit file in the directory dir of fsys, in file name
+// order, and merges them into one package as MergeFiles does. The
+// diagnostics of all files are combined into the returned error.
+func ParseFS(fsys fs.FS, dir string) (*ast.PackageSet, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFiles(witFiles(entries), func(name string) (*ast.AST, error) {
+		name = path.Join(dir, name)
+
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		return parse(lexer.NewReader(f, name), name)
+	})
+}
+
+// ParseDir parses every .wit file in the directory dir, as ParseFS does.
+func ParseDir(dir string) (*ast.PackageSet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFiles(witFiles(entries), func(name string) (*ast.AST, error) {
+		return ParseFile(filepath.Join(dir, name))
+	})
+}
+
+func parse(l *lexer.Lexer, filename string) (*ast.AST, error) {
+	p := New(l)
+
+	tree := p.Parse()
+	tree.Filename = filename
+
+	return tree, p.Errors().Err()
+}
+
+// parseFiles parses each of names and merges the files, stopping at the
+// first error that is not a list of diagnostics.
+func parseFiles(names []string, parseFile func(name string) (*ast.AST, error)) (*ast.PackageSet, error) {
+	var (
+		trees []*ast.AST
+		errs  diagnostic.DiagnosticList
+	)
+
+	for _, name := range names {
+		tree, err := parseFile(name)
+		if err != nil {
+			diags, ok := err.(diagnostic.DiagnosticList)
+			if !ok {
+				return nil, err
+			}
+			errs = append(errs, diags...)
+		}
+
+		trees = append(trees, tree)
+	}
+
+	pkg, err := MergeFiles(trees...)
+	if err != nil {
+		errs = append(errs, err.(diagnostic.DiagnosticList)...)
+	}
+
+	return pkg, errs.Err()
+}
+
+// MergeFiles combines files belonging to one package. Files may omit the
+// package declaration, but those that have one must agree on it. Interfaces
+// and worlds share one namespace across all files, so a name defined twice
+// is reported with both locations. Nested package blocks with the same name
+// are merged into one, with a namespace of its own. Errors are returned as a
+// diagnostic.DiagnosticList; the package holds every file regardless.
+func MergeFiles(files ...*ast.AST) (*ast.PackageSet, error) {
+	var errs diagnostic.DiagnosticList
+
+	pkg := &ast.PackageSet{Files: files}
+	names := make(map[string]token.Span)
+
+	// nested package blocks and the names defined in them, by package name
+	nested := make(map[string]*ast.Package)
+	nestedNames := make(map[string]map[string]token.Span)
+
+	define := func(names map[string]token.Span, name string, span token.Span) {
+		if prev, ok := names[name]; ok {
+			d := errs.Addf(span, ERROR_DUPLICATE_NAME, "%s is defined more than once", name)
+			d.Notes = append(d.Notes, diagnostic.Note{
+				Span:    prev,
+				Message: "previous definition of " + name + " here",
+			})
+			return
+		}
+		names[name] = span
+	}
+
+	for _, f := range files {
+		if decl := f.Package; decl != nil {
+			switch {
+			case pkg.Package == nil:
+				pkg.Package = decl
+			case packageName(decl) != packageName(pkg.Package):
+				d := errs.Addf(decl.Span, ERROR_PACKAGE_MISMATCH,
+					"package %s does not match %s", packageName(decl), packageName(pkg.Package))
+				d.Notes = append(d.Notes, diagnostic.Note{
+					Span:    pkg.Package.Span,
+					Message: "package first declared here",
+				})
+			}
+		}
+
+		pkg.Uses = append(pkg.Uses, f.Uses...)
+
+		for _, i := range f.Interfaces {
+			define(names, i.Name, i.Identifier.Token.Span())
+			pkg.Interfaces = append(pkg.Interfaces, i)
+		}
+
+		for _, w := range f.Worlds {
+			define(names, w.Name, w.Identifier.Token.Span())
+			pkg.Worlds = append(pkg.Worlds, w)
+		}
+
+		for _, block := range f.Packages {
+			name := packageName(block)
+
+			merged, ok := nested[name]
+			if !ok {
+				merged = &ast.Package{
+					Identifier: block.Identifier,
+					Docs:       block.Docs,
+					Namespace:  block.Namespace,
+					Name:       block.Name,
+					Version:    block.Version,
+					Span:       block.Span,
+				}
+				nested[name] = merged
+				nestedNames[name] = make(map[string]token.Span)
+				pkg.Packages = append(pkg.Packages, merged)
+			}
+
+			for _, i := range block.Interfaces {
+				define(nestedNames[name], i.Name, i.Identifier.Token.Span())
+				merged.Interfaces = append(merged.Interfaces, i)
+			}
+
+			for _, w := range block.Worlds {
+				define(nestedNames[name], w.Name, w.Identifier.Token.Span())
+				merged.Worlds = append(merged.Worlds, w)
+			}
+		}
+	}
+
+	return pkg, errs.Err()
+}
+
+// packageName returns the full name of a package declaration, including
+// its version.
+func packageName(p *ast.Package) string {
+	name := p.Namespace + ":" + p.Name
+	if p.Version != nil {
+		name += "@" + p.Version.String()
+	}
+	return name
+}
+
+// witFiles returns the names of the .wit files among entries.
+func witFiles(entries []fs.DirEntry) []string {
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".wit") {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
@@ -0,0 +1,111 @@
+// Parsing feature gates
+// Documentation: https://github.com/WebAssembly/component-model/blob/main/design/mvp/WIT.md#feature-gates
+package parser
+
+import (
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/token"
+)
+
+// gate ::= '@' gate-name '(' gate-args ')'
+//
+// gate-name ::= 'since' | 'unstable' | 'deprecated'
+//
+// gate-args ::= gate-arg
+//             | gate-arg ',' gate-args?
+//
+// gate-arg ::= 'version' '=' valid-semver
+//            | 'feature' '=' id
+
+// parseGates parses the feature gates in front of an item while the peek
+// token is `@`. Documentation comments written before the gates stay
+// attached to the item. It returns false after reporting an error.
+func (p *Parser) parseGates() (ast.Gates, bool) {
+	var gates ast.Gates
+	docs := p.peekDocs
+
+	for p.peekToken.Type == token.OP_AT {
+		p.nextToken()
+
+		g := p.parseGate()
+		if g == nil {
+			return nil, false
+		}
+		gates = append(gates, g)
+	}
+
+	if p.peekDocs == nil {
+		p.peekDocs = docs
+	}
+
+	return gates, true
+}
+
+func (p *Parser) parseGate() *ast.Gate {
+	g := new(ast.Gate)
+	g.Token = p.curToken
+
+	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
+		return nil
+	}
+
+	g.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	switch g.Name.Value {
+	case ast.GATE_SINCE, ast.GATE_UNSTABLE, ast.GATE_DEPRECATED:
+	default:
+		p.errorf(p.curToken.Span(), ERROR_INVALID_GATE, "unknown attribute @%s", g.Name.Value)
+		return nil
+	}
+
+	if !p.expectNextToken(token.OP_BRACKET_PAREN_LEFT) {
+		p.expectError(token.OP_BRACKET_PAREN_LEFT)
+		return nil
+	}
+
+	ok := p.parseList(token.OP_BRACKET_PAREN_RIGHT, func() bool {
+		if !p.expectNextToken(token.IDENTIFIER) {
+			p.expectError(token.IDENTIFIER)
+			return false
+		}
+		key := p.curToken
+
+		if !p.expectNextToken(token.OP_EQUAL) {
+			p.expectError(token.OP_EQUAL)
+			return false
+		}
+
+		switch key.Literal {
+		case "version":
+			g.Version = p.parseSemVer()
+			return g.Version != nil
+		case "feature":
+			if !p.expectNextToken(token.IDENTIFIER) {
+				p.expectError(token.IDENTIFIER)
+				return false
+			}
+			g.Feature = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			return true
+		default:
+			p.errorf(key.Span(), ERROR_INVALID_GATE, "unknown argument %s to @%s", key.Literal, g.Name.Value)
+			return false
+		}
+	})
+	if !ok {
+		return nil
+	}
+
+	g.Span = p.spanFrom(g.Token)
+
+	switch {
+	case g.Name.Value == ast.GATE_UNSTABLE && g.Feature == nil:
+		p.errorf(g.Span, ERROR_INVALID_GATE, "@%s requires a feature", g.Name.Value)
+		return nil
+	case g.Name.Value != ast.GATE_UNSTABLE && g.Version == nil:
+		p.errorf(g.Span, ERROR_INVALID_GATE, "@%s requires a version", g.Name.Value)
+		return nil
+	}
+
+	return g
+}
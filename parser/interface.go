@@ -10,13 +10,22 @@ import (
 func (p *Parser) parseInterfaceShape() *ast.Interface {
 	iFace := new(ast.Interface)
 	iFace.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	iFace.Docs = p.docs()
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
 	iFace.Name = p.curToken.Literal
-	iFace.Items = *p.parseInterfaceItems()
+
+	ii := p.parseInterfaceItems()
+	if ii == nil {
+		return nil
+	}
+
+	iFace.Items = *ii
+	iFace.Span = p.spanFrom(iFace.Identifier.Token)
 
 	return iFace
 }
@@ -25,35 +34,55 @@ func (p *Parser) parseInterfaceItems() *ast.InterfaceItems {
 	ii := new(ast.InterfaceItems)
 
 	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
+		p.expectError(token.OP_BRACKET_CURLY_LEFT)
 		return nil
 	}
+	start := p.curToken
+
+	p.parseBody(func() bool {
+		gates, ok := p.parseGates()
+		if !ok {
+			return false
+		}
 
-	for p.peekToken.Type != token.OP_BRACKET_CURLY_RIGHT {
 		switch p.peekToken.Type {
 		// USE ITEMS -----------------------
 		case token.KEYWORD_USE:
-			if !p.expectNextToken(token.KEYWORD_USE) {
-				return nil
+			p.nextToken()
+			us := p.parseUseShape()
+			if us == nil {
+				return false
 			}
-			// TODO: check this parser
-			ii.UseItems = append(ii.UseItems, p.parseUseShape())
+			us.Gates = gates
+			ii.UseItems = append(ii.UseItems, us)
 
 		// FUNC ITEMS ----------------------
 		case token.IDENTIFIER: // derp: func() -> foo
-			s := p.parseFuncItem()
-			if s != nil {
-				ii.FuncItems = append(ii.FuncItems, s)
+			fs := p.parseFuncItem()
+			if fs == nil {
+				return false
 			}
+			fs.Gates = gates
+			ii.FuncItems = append(ii.FuncItems, fs)
 
 		// TYPEDEFS ------------------------
 		default:
-			ii.TypedefItems = append(ii.TypedefItems, p.parseTypeDef())
+			td := p.parseTypeDef()
+			if td == nil {
+				return false
+			}
+			td.Gates = gates
+			ii.TypedefItems = append(ii.TypedefItems, td)
 		}
-	}
 
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
+		return true
+	})
+
+	if !p.closeBody() {
 		return nil
 	}
 
+	ii.Span = p.spanFrom(start)
+
 	return ii
 }
@@ -7,22 +7,32 @@ import (
 
 func (p *Parser) parseFuncItem() *ast.FuncShape {
 	fs := new(ast.FuncShape)
-	fs.Token = p.curToken
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
 	fs.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	fs.Docs = p.docs()
 
 	if !p.expectNextToken(token.OP_COLON) {
+		p.expectError(token.OP_COLON)
 		return nil
 	}
 
 	if !p.expectNextToken(token.KEYWORD_FUNC) {
+		p.expectError(token.KEYWORD_FUNC)
+		return nil
+	}
+	fs.Token = p.curToken
+
+	ft := p.parseFuncType()
+	if ft == nil {
 		return nil
 	}
-	fs.Value = p.parseFuncType()
+	fs.Func = ft
+	fs.Span = p.spanFrom(fs.Name.Token)
 
 	return fs
 }
@@ -31,57 +41,72 @@ func (p *Parser) parseFuncType() *ast.FuncType {
 	ft := new(ast.FuncType)
 	ft.Token = p.curToken
 
-	ft.ParamList = p.parseParamList()
+	if ft.ParamList = p.parseParamList(); ft.ParamList == nil {
+		return nil
+	}
 
 	if !p.expectNextToken(token.OP_ARROW) {
-		ft.ResultList = nil
-		return ft // result list can be empty
+		ft.Span = p.spanFrom(ft.Token)
+		return ft // no results
 	}
 
-	expectComma := false
-	// result-list
+	// result-list ::= ty
+	//               | '(' named-type-list ')'
 	if p.expectNextToken(token.OP_BRACKET_PAREN_LEFT) {
-		for p.expectNextToken(token.OP_BRACKET_PAREN_RIGHT) {
-			switch p.peekToken.Type {
-			case token.IDENTIFIER:
-				*ft.ResultList = append(*ft.ResultList, p.parseNamedType())
-				expectComma = true
-			case token.OP_COMMA:
-				if expectComma {
-					if !p.expectNextToken(token.OP_COMMA) {
-						return nil
-					}
-					expectComma = false
-					p.nextToken() // eat comma
-					break
-				}
-				fallthrough
-			default:
-				// invalid token
-				p.nextToken()
+		results := ast.ResultList{}
+
+		ok := p.parseList(token.OP_BRACKET_PAREN_RIGHT, func() bool {
+			nt := p.parseNamedType()
+			if nt == nil {
+				return false
 			}
+			results = append(results, nt)
+			return true
+		})
+		if !ok {
+			return nil
 		}
+
+		ft.ResultList = &results
 	} else {
-		ft.ResultList = &ast.ResultList{p.parseTy()}
+		nt := &ast.NamedType{Token: p.peekToken}
+		if nt.Type = p.parseTy(); nt.Type == nil {
+			return nil
+		}
+		nt.Span = p.spanFrom(nt.Token)
+		ft.ResultList = &ast.ResultList{nt}
 	}
 
+	ft.Span = p.spanFrom(ft.Token)
+
 	return ft
 }
 
+// named-type ::= id ':' ty
+
 func (p *Parser) parseNamedType() *ast.NamedType {
 	nt := new(ast.NamedType)
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
-	nt.Id = &ast.Identifier{Token: p.curToken}
+	nt.Token = p.curToken
+	nt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.expectNextToken(token.OP_COLON) {
+		p.expectError(token.OP_COLON)
 		return nil
 	}
 
-	nt.Ty = p.parseTypeShape()
+	ty := p.parseTy()
+	if ty == nil {
+		return nil
+	}
+
+	nt.Type = ty
+	nt.Span = p.spanFrom(nt.Token)
 
 	return nt
 }
@@ -90,37 +115,19 @@ func (p *Parser) parseParamList() *ast.ParamList {
 	paramList := new(ast.ParamList)
 
 	if !p.expectNextToken(token.OP_BRACKET_PAREN_LEFT) {
+		p.expectError(token.OP_BRACKET_PAREN_LEFT)
 		return nil
 	}
 
-	expectComma := false
-
-	for p.peekToken.Type != token.OP_BRACKET_PAREN_RIGHT {
-		switch p.peekToken.Type {
-		case token.IDENTIFIER:
-			if !p.expectNextToken(token.IDENTIFIER) {
-				return nil
-			}
-
-			*paramList = append(*paramList, p.parseNamedType())
-			expectComma = true
-		case token.OP_COMMA:
-			if expectComma {
-				if !p.expectNextToken(token.OP_COMMA) {
-					return nil
-				}
-				expectComma = false
-				p.nextToken() // eat comma
-				break
-			}
-			fallthrough
-		default:
-			// invalid token
-			p.nextToken()
+	ok := p.parseList(token.OP_BRACKET_PAREN_RIGHT, func() bool {
+		nt := p.parseNamedType()
+		if nt == nil {
+			return false
 		}
-	}
-
-	if !p.expectNextToken(token.OP_BRACKET_PAREN_RIGHT) {
+		*paramList = append(*paramList, nt)
+		return true
+	})
+	if !ok {
 		return nil
 	}
 
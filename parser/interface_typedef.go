@@ -7,56 +7,94 @@ import (
 
 func (p *Parser) parseTypeDef() *ast.TypeDef {
 	td := new(ast.TypeDef)
-	td.Token = p.curToken
+	td.Token = p.peekToken
+	td.Docs = newDocs(p.peekDocs)
 
 	switch p.peekToken.Type {
 	case token.KEYWORD_RESOURCE:
 		if !p.expectNextToken(token.KEYWORD_RESOURCE) {
 			return nil
 		}
-		//TODO: IMPLEMENT
+
+		rs := p.parseResourceShape()
+		if rs == nil {
+			return nil
+		}
+		td.Name, td.Kind = rs.Name, rs
 
 	case token.KEYWORD_VARIANT:
 		if !p.expectNextToken(token.KEYWORD_VARIANT) {
 			return nil
 		}
-		//TODO: IMPLEMENT
+
+		vs := p.parseVariantShape()
+		if vs == nil {
+			return nil
+		}
+		td.Name, td.Kind = vs.Identifier, vs
 
 	case token.KEYWORD_RECORD:
 		if !p.expectNextToken(token.KEYWORD_RECORD) {
 			return nil
 		}
-		//TODO: IMPLEMENT
+
+		rs := p.parseRecordShape()
+		if rs == nil {
+			return nil
+		}
+		td.Name, td.Kind = rs.Identifier, rs
 
 	case token.KEYWORD_UNION:
 		if !p.expectNextToken(token.KEYWORD_UNION) {
 			return nil
 		}
-		//TODO: IMPLEMENT
+
+		us := p.parseUnionShape()
+		if us == nil {
+			return nil
+		}
+		td.Name, td.Kind = us.Name, us
 
 	case token.KEYWORD_FLAGS:
 		if !p.expectNextToken(token.KEYWORD_FLAGS) {
 			return nil
 		}
-		//TODO: IMPLEMENT
+
+		fs := p.parseFlagShape()
+		if fs == nil {
+			return nil
+		}
+		td.Name, td.Kind = fs.Name, fs
 
 	case token.KEYWORD_ENUM:
 		if !p.expectNextToken(token.KEYWORD_ENUM) {
 			return nil
 		}
-		//TODO: IMPLEMENT
+
+		es := p.parseEnumShape()
+		if es == nil {
+			return nil
+		}
+		td.Name, td.Kind = es.Name, es
 
 	case token.KEYWORD_TYPE:
 		if !p.expectNextToken(token.KEYWORD_TYPE) {
 			return nil
 		}
 
-		td.Value = p.parseTypeShape()
+		ts := p.parseTypeShape()
+		if ts == nil {
+			return nil
+		}
+		td.Name, td.Kind = ts.Name, ts
 
 	default:
-		// unknown token error
+		p.errorf(p.peekToken.Span(), ERROR_UNEXPECTED_TOKEN, "unexpected token: %s", p.peekToken.Literal)
 		p.nextToken()
+		return nil
 	}
 
+	td.Span = p.spanFrom(td.Token)
+
 	return td
 }
@@ -15,39 +15,41 @@ func (p *Parser) parseEnumShape() *ast.EnumShape {
 	es.Token = p.curToken
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
-	es.Name = &ast.Identifier{Token: p.curToken}
+	es.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
+		p.expectError(token.OP_BRACKET_CURLY_LEFT)
 		return nil
 	}
-	expectComma := false
-	for p.peekToken.Type != token.OP_BRACKET_CURLY_RIGHT {
-		switch p.peekToken.Type {
-		case token.IDENTIFIER:
-			es.Value = append(es.Value, p.parseTy())
-			expectComma = true
-		case token.OP_COMMA:
-			if expectComma {
-				if !p.expectNextToken(token.OP_COMMA) {
-					return nil
-				}
-
-				expectComma = false
-				break
-			}
-			fallthrough
-		default:
-			//invalid token
-			p.nextToken()
+
+	ok := p.parseList(token.OP_BRACKET_CURLY_RIGHT, func() bool {
+		gates, ok := p.parseGates()
+		if !ok {
+			return false
 		}
-	}
 
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
+		if p.peekToken.Type != token.IDENTIFIER {
+			p.expectError(token.IDENTIFIER)
+			return false
+		}
+		p.nextToken()
+
+		ec := &ast.EnumCase{Gates: gates}
+		ec.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		ec.Docs = p.docs()
+		ec.Span = p.curToken.Span()
+		es.Cases = append(es.Cases, ec)
+		return true
+	})
+	if !ok {
 		return nil
 	}
 
+	es.Span = p.spanFrom(es.Token)
+
 	return es
 }
@@ -15,39 +15,41 @@ func (p *Parser) parseFlagShape() *ast.FlagShape {
 	fs.Token = p.curToken
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
-	fs.Name = &ast.Identifier{Token: p.curToken}
+	fs.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
+		p.expectError(token.OP_BRACKET_CURLY_LEFT)
 		return nil
 	}
-	expectComma := false
-	for p.peekToken.Type != token.OP_BRACKET_CURLY_RIGHT {
-		switch p.peekToken.Type {
-		case token.IDENTIFIER:
-			fs.Value = append(fs.Value, p.parseTy())
-			expectComma = true
-		case token.OP_COMMA:
-			if expectComma {
-				if !p.expectNextToken(token.OP_COMMA) {
-					return nil
-				}
-
-				expectComma = false
-				break
-			}
-			fallthrough
-		default:
-			//invalid token
-			p.nextToken()
+
+	ok := p.parseList(token.OP_BRACKET_CURLY_RIGHT, func() bool {
+		gates, ok := p.parseGates()
+		if !ok {
+			return false
 		}
-	}
 
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
+		if p.peekToken.Type != token.IDENTIFIER {
+			p.expectError(token.IDENTIFIER)
+			return false
+		}
+		p.nextToken()
+
+		f := &ast.Flag{Gates: gates}
+		f.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		f.Docs = p.docs()
+		f.Span = p.curToken.Span()
+		fs.Flags = append(fs.Flags, f)
+		return true
+	})
+	if !ok {
 		return nil
 	}
 
+	fs.Span = p.spanFrom(fs.Token)
+
 	return fs
 }
@@ -17,51 +17,53 @@ func (p *Parser) parseRecordShape() *ast.RecordShape {
 	rs.Token = p.curToken
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
-	rs.Identifier = &ast.Identifier{Token: p.curToken}
+	rs.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
+		p.expectError(token.OP_BRACKET_CURLY_LEFT)
 		return nil
 	}
 
-	expectComma := false
-	for p.peekToken.Type != token.OP_BRACKET_CURLY_RIGHT {
-		switch p.peekToken.Type {
-		case token.IDENTIFIER:
-			rf := new(ast.RecordField)
-			rf.Token = p.curToken
+	ok := p.parseList(token.OP_BRACKET_CURLY_RIGHT, func() bool {
+		gates, ok := p.parseGates()
+		if !ok {
+			return false
+		}
 
-			if !p.expectNextToken(token.IDENTIFIER) {
-				return nil
-			}
-			rf.Identifier = &ast.Identifier{Token: p.curToken}
+		rf := new(ast.RecordField)
+		rf.Token = p.curToken
+		rf.Gates = gates
 
-			if !p.expectNextToken(token.OP_COLON) {
-				return nil
-			}
+		if !p.expectNextToken(token.IDENTIFIER) {
+			p.expectError(token.IDENTIFIER)
+			return false
+		}
+		rf.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		rf.Docs = p.docs()
 
-			rf.Ty = p.parseTy()
-			rs.Value = append(rs.Value, rf)
-			expectComma = true
-		case token.OP_COMMA:
-			if expectComma {
-				if !p.expectNextToken(token.OP_COMMA) {
-					return nil
-				}
-				expectComma = false
-				break
-			}
-			fallthrough
-		default:
-			// invalid token
-			p.nextToken()
+		if !p.expectNextToken(token.OP_COLON) {
+			p.expectError(token.OP_COLON)
+			return false
 		}
-	}
 
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
+		ty := p.parseTy()
+		if ty == nil {
+			return false
+		}
+
+		rf.Type = ty
+		rf.Span = p.spanFrom(rf.Identifier.Token)
+		rs.Fields = append(rs.Fields, rf)
+		return true
+	})
+	if !ok {
 		return nil
 	}
+
+	rs.Span = p.spanFrom(rs.Token)
 	return rs
 }
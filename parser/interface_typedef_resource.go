@@ -3,18 +3,15 @@
 package parser
 
 import (
-	"errors"
-	"fmt"
-
 	"github.com/jordan-rash/go-wit/ast"
 	"github.com/jordan-rash/go-wit/token"
 )
 
-// resource-item ::= 'resource' id resource-methods?
-// resource-methods ::= '{' resource-method* '}'
+// resource-item ::= 'resource' id ';'
+//                 | 'resource' id '{' resource-method* '}'
 // resource-method ::= func-item
 //                   | id ':' 'static' func-type
-//                   | 'constructor' param-list
+//                   | 'constructor' param-list result-list?
 //
 // param-list ::= '(' named-type-list ')'
 //
@@ -27,62 +24,138 @@ func (p *Parser) parseResourceShape() *ast.ResourceShape {
 	resource.Token = p.curToken
 
 	if !p.expectNextToken(token.IDENTIFIER) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected IDENT, got %s", p.peekToken.Type))
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
 	resource.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected BRACKET_CURLY_LEFT, got %s", p.peekToken.Type))
-		return nil
+		// a resource without methods has no body
+		p.expectNextToken(token.OP_SEMICOLON)
+		resource.Span = p.spanFrom(resource.Token)
+		return resource
 	}
 
-	for p.peekToken.Type != token.OP_BRACKET_CURLY_RIGHT {
+	p.parseBody(func() bool {
+		gates, ok := p.parseGates()
+		if !ok {
+			return false
+		}
+
 		switch p.peekToken.Type {
 		case token.IDENTIFIER:
-			if !p.expectNextToken(token.IDENTIFIER) {
-				return nil
-			}
-			if !p.expectNextToken(token.OP_COLON) {
-				return nil
+			fs := p.parseResourceFunc()
+			if fs == nil {
+				return false
 			}
-			switch p.peekToken.Type {
-			case token.KEYWORD_FUNC:
-				resource.Value = append(resource.Value, p.parseFuncType())
-
-			case token.KEYWORD_STATIC:
-				if !p.expectNextToken(token.KEYWORD_STATIC) {
-					return nil
-				}
-				if !p.expectNextToken(token.KEYWORD_FUNC) {
-					return nil
-				}
-				resource.Value = append(resource.Value, p.parseFuncType())
-
-			default:
-				// invalid token
-				p.nextToken()
+			fs.Gates = gates
+
+			if fs.Static {
+				resource.StaticFuncs = append(resource.StaticFuncs, fs)
+			} else {
+				resource.Methods = append(resource.Methods, fs)
 			}
 
 		case token.KEYWORD_CONSTRUCTOR:
-			if !p.expectNextToken(token.KEYWORD_CONSTRUCTOR) {
-				return nil
+			if resource.Constructor != nil {
+				p.errorf(p.peekToken.Span(), ERROR_INVALID_CTOR, "resource %s has more than one constructor", resource.Name.Value)
 			}
 
-			resource.Value = append(resource.Value, p.parseParamList())
+			fs := p.parseConstructor()
+			if fs == nil {
+				return false
+			}
+			fs.Gates = gates
+
+			if resource.Constructor == nil {
+				resource.Constructor = fs
+			}
 
 		default:
-			// invalid token
-			p.nextToken()
+			p.errorf(p.peekToken.Span(), ERROR_UNEXPECTED_TOKEN, "expected a resource method, got %s", p.peekToken.Literal)
+			return false
 		}
-	}
 
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected BRACKET_CURLY_RIGHT, got %s", p.peekToken.Type))
+		return true
+	})
+
+	if !p.closeBody() {
 		return nil
 	}
 
-	p.nextToken() // eat RIGHT CURLY
+	resource.Span = p.spanFrom(resource.Token)
+
 	return resource
 }
+
+// parseResourceFunc parses a method or, with `static`, a static function of
+// a resource.
+func (p *Parser) parseResourceFunc() *ast.FuncShape {
+	p.nextToken()
+
+	fs := new(ast.FuncShape)
+	fs.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	fs.Docs = p.docs()
+
+	if !p.expectNextToken(token.OP_COLON) {
+		p.expectError(token.OP_COLON)
+		return nil
+	}
+
+	if p.expectNextToken(token.KEYWORD_STATIC) {
+		fs.Static = true
+	}
+
+	if !p.expectNextToken(token.KEYWORD_FUNC) {
+		p.expectError(token.KEYWORD_FUNC)
+		return nil
+	}
+	fs.Token = p.curToken
+
+	ft := p.parseFuncType()
+	if ft == nil {
+		return nil
+	}
+	fs.Func = ft
+	fs.Span = p.spanFrom(fs.Name.Token)
+
+	return fs
+}
+
+// parseConstructor parses a resource constructor. It may only return a
+// result, as in `constructor(path: string) -> result<file, error>`.
+func (p *Parser) parseConstructor() *ast.FuncShape {
+	p.nextToken()
+
+	fs := new(ast.FuncShape)
+	fs.Token = p.curToken
+	fs.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	fs.Docs = p.docs()
+
+	ft := p.parseFuncType()
+	if ft == nil {
+		return nil
+	}
+
+	if rl := ft.ResultList; rl != nil && (len(*rl) != 1 || !isResultType((*rl)[0])) {
+		span := ft.Span
+		if len(*rl) > 0 {
+			span = token.Span{Start: rl.Pos(), End: rl.End()}
+		}
+		p.errorf(span, ERROR_INVALID_CTOR, "constructor of a resource can only return a result")
+	}
+
+	fs.Func = ft
+	fs.Span = p.spanFrom(fs.Token)
+
+	return fs
+}
+
+func isResultType(nt *ast.NamedType) bool {
+	if nt == nil || nt.Name != nil {
+		return false
+	}
+	_, ok := nt.Type.(*ast.Result)
+	return ok
+}
@@ -18,58 +18,68 @@ func (p *Parser) parseVariantShape() *ast.VariantShape {
 	vs.Token = p.curToken
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
-	vs.Identifier = &ast.Identifier{Token: p.curToken}
+	vs.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
+		p.expectError(token.OP_BRACKET_CURLY_LEFT)
 		return nil
 	}
 
-	expectComma := false
-	for p.peekToken.Type != token.OP_BRACKET_CURLY_RIGHT {
-		switch p.peekToken.Type {
-		case token.OP_COMMA:
-			if expectComma {
-				if !p.expectNextToken(token.OP_COMMA) {
-					return nil
-				}
-				expectComma = false
-				break
-			}
-		default:
-			vs.Value = append(vs.Value, p.parseVariantCase())
-			expectComma = true
+	ok := p.parseList(token.OP_BRACKET_CURLY_RIGHT, func() bool {
+		vc := p.parseVariantCase()
+		if vc == nil {
+			return false
 		}
-	}
-
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
+		vs.Cases = append(vs.Cases, vc)
+		return true
+	})
+	if !ok {
 		return nil
 	}
 
+	vs.Span = p.spanFrom(vs.Token)
+
 	return vs
 }
 
 func (p *Parser) parseVariantCase() *ast.VariantCase {
+	gates, ok := p.parseGates()
+	if !ok {
+		return nil
+	}
+
 	vc := new(ast.VariantCase)
 	vc.Token = p.curToken
+	vc.Gates = gates
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
-	vc.Identifier = &ast.Identifier{Token: p.curToken}
+	vc.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	vc.Docs = p.docs()
 
 	if !p.expectNextToken(token.OP_BRACKET_PAREN_LEFT) {
-		vc.Value = nil
+		vc.Span = p.spanFrom(vc.Identifier.Token)
 		return vc
 	}
 
-	vc.Value = p.parseTy()
+	ty := p.parseTy()
+	if ty == nil {
+		return nil
+	}
+	vc.Type = ty
 
 	if !p.expectNextToken(token.OP_BRACKET_PAREN_RIGHT) {
+		p.expectError(token.OP_BRACKET_PAREN_RIGHT)
 		return nil
 	}
 
+	vc.Span = p.spanFrom(vc.Identifier.Token)
+
 	return vc
 }
@@ -5,34 +5,30 @@ import (
 	"github.com/jordan-rash/go-wit/token"
 )
 
+// use-item ::= 'use' use-path '.' '{' use-names-list '}'
+
 func (p *Parser) parseUseShape() *ast.UseShape {
 	stmt := new(ast.UseShape)
 	stmt.Token = p.curToken
+	stmt.Docs = p.docs()
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-
-	if !p.expectNextToken(token.OP_PERIOD) {
+	stmt.UseInterface.Path = p.parseUsePath()
+	if stmt.UseInterface.Path == nil {
 		return nil
 	}
 
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
-		return nil
-	}
+	stmt.Name = stmt.UseInterface.Path.Name
 
-	// TODO: this can be 0..n identifiers
-	if !p.expectNextToken(token.IDENTIFIER) {
+	if !p.parseUseNames(&stmt.UseInterface) {
 		return nil
 	}
 
-	stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
-		return nil
-	}
+	stmt.Span = p.spanFrom(stmt.Token)
 
 	return stmt
 }
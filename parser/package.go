@@ -9,25 +9,29 @@ func (p *Parser) parsePackageShape() *ast.Package {
 	pkg := new(ast.Package)
 
 	pkg.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	pkg.Docs = p.docs()
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
 	pkg.Namespace = p.curToken.Literal
 
 	if !p.expectNextToken(token.OP_COLON) {
+		p.expectError(token.OP_COLON)
 		return nil
 	}
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
 	pkg.Name = p.curToken.Literal
 
 	if p.peekToken.Literal != token.OP_AT {
-		pkg.SemVer = ""
+		pkg.Span = p.spanFrom(pkg.Identifier.Token)
 		return pkg
 	}
 
@@ -35,8 +39,54 @@ func (p *Parser) parsePackageShape() *ast.Package {
 		return nil
 	}
 
-	sv := p.parseSemVer()
-	pkg.SemVer = sv.String()
+	pkg.Version = p.parseSemVer()
+	pkg.Span = p.spanFrom(pkg.Identifier.Token)
 
 	return pkg
 }
+
+// parsePackageBlock parses the interfaces and worlds of a nested package
+// block, `package ns:name { ... }`, after its opening brace. It reports
+// whether the block was closed, see closeBody.
+func (p *Parser) parsePackageBlock(pkg *ast.Package) bool {
+	for !p.peekIsEnd(token.OP_BRACKET_CURLY_RIGHT) && p.peekToken.Type != token.KEYWORD_PACKAGE {
+		offset := p.peekToken.Pos.Offset
+
+		gates, ok := p.parseGates()
+
+		switch {
+		case !ok:
+			p.synchronize(topLevelKeywords, false)
+		case p.peekToken.Type == token.KEYWORD_INTERFACE:
+			p.nextToken()
+			if i := p.parseInterfaceShape(); i != nil {
+				i.Gates = gates
+				pkg.Interfaces = append(pkg.Interfaces, i)
+			} else {
+				p.synchronize(topLevelKeywords, false)
+			}
+		case p.peekToken.Type == token.KEYWORD_WORLD:
+			p.nextToken()
+			if w := p.parseWorldShape(); w != nil {
+				w.Gates = gates
+				pkg.Worlds = append(pkg.Worlds, w)
+			} else {
+				p.synchronize(topLevelKeywords, false)
+			}
+		default:
+			p.errorf(p.peekToken.Span(), ERROR_UNEXPECTED_TOKEN, "invalid token in package %s:%s: %s [%s]", pkg.Namespace, pkg.Name, p.peekToken.Literal, p.peekToken.Type)
+			p.synchronize(topLevelKeywords, false)
+		}
+
+		if p.peekToken.Pos.Offset == offset && !p.peekIsEnd(token.OP_BRACKET_CURLY_RIGHT) && p.peekToken.Type != token.KEYWORD_PACKAGE {
+			p.nextToken()
+		}
+	}
+
+	if !p.closeBody() {
+		return false
+	}
+
+	pkg.Span = p.spanFrom(pkg.Identifier.Token)
+	return true
+}
@@ -1,18 +1,24 @@
 package parser
 
 import (
-	"errors"
 	"fmt"
+	"unicode"
 
 	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/diagnostic"
 	"github.com/jordan-rash/go-wit/lexer"
 	"github.com/jordan-rash/go-wit/token"
 )
 
-var (
-	WRONG_NEXT_TYPE_ERROR func(got, expected string) error = func(got, expected string) error {
-		return fmt.Errorf("unexpected next type.  Got: %s\tExpected: %s", got, expected)
-	}
+// Diagnostic codes reported by the parser
+const (
+	ERROR_UNEXPECTED_TOKEN = "P0001"
+	ERROR_EXPECTED_TOKEN   = "P0002"
+	ERROR_INVALID_SEMVER   = "P0003"
+	ERROR_PACKAGE_MISMATCH = "P0004"
+	ERROR_DUPLICATE_NAME   = "P0005"
+	ERROR_INVALID_GATE     = "P0006"
+	ERROR_INVALID_CTOR     = "P0007"
 )
 
 type Parser struct {
@@ -21,10 +27,18 @@ type Parser struct {
 	curToken  token.Token
 	peekToken token.Token
 
-	errors error
+	// documentation comments preceding curToken and peekToken
+	curDocs  []token.Token
+	peekDocs []token.Token
+
+	// position of the item ending bodies left open, see closeBody
+	unclosed token.Position
+
+	errors diagnostic.DiagnosticList
 }
 
-// TODO: change input to string and create lexer
+// New returns a parser reading tokens from l. ParseString, ParseFile and
+// ParseFS cover the common cases of parsing a whole file.
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{lexer: l}
 
@@ -35,47 +49,363 @@ func New(l *lexer.Lexer) *Parser {
 	return p
 }
 
-func (p Parser) Errors() error {
-	return p.errors
+// Errors returns the diagnostics collected while lexing and parsing. Use
+// Err to get a nil error when parsing succeeded.
+func (p Parser) Errors() diagnostic.DiagnosticList {
+	lexErrs := p.lexer.Errors()
+	if len(lexErrs) == 0 {
+		return p.errors
+	}
+
+	errs := make(diagnostic.DiagnosticList, 0, len(lexErrs)+len(p.errors))
+	errs = append(errs, lexErrs...)
+	errs = append(errs, p.errors...)
+	errs.Sort()
+
+	return errs
 }
 
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
+	p.curDocs = p.peekDocs
+
+	p.peekDocs = nil
 	p.peekToken = p.lexer.NextToken()
+	for p.peekToken.Type == token.COMMENT_DOCUMENTATION {
+		p.peekDocs = append(p.peekDocs, p.peekToken)
+		p.peekToken = p.lexer.NextToken()
+	}
+}
+
+// docs returns the documentation comments attached to the current token.
+func (p *Parser) docs() *ast.Docs {
+	return newDocs(p.curDocs)
+}
+
+func newDocs(comments []token.Token) *ast.Docs {
+	if len(comments) == 0 {
+		return nil
+	}
+	return &ast.Docs{Comments: comments}
 }
 
 func (p *Parser) Parse() *ast.AST {
 	tree := new(ast.AST)
 
 	for p.peekToken.Type != token.END_OF_FILE {
-		switch p.peekToken.Type {
-		case token.KEYWORD_INTERFACE:
-			if !p.expectNextToken(token.KEYWORD_INTERFACE) {
-				return nil
+		offset := p.peekToken.Pos.Offset
+
+		if gates, ok := p.parseGates(); ok {
+			p.parseTopLevelItem(tree, gates)
+		} else {
+			p.synchronize(topLevelKeywords, false)
+		}
+
+		if p.peekToken.Pos.Offset == offset && p.peekToken.Type != token.END_OF_FILE {
+			p.nextToken()
+		}
+	}
+
+	return tree
+}
+
+// parseTopLevelItem parses the item starting at the peek token into tree.
+func (p *Parser) parseTopLevelItem(tree *ast.AST, gates ast.Gates) {
+	switch p.peekToken.Type {
+	case token.KEYWORD_INTERFACE:
+		p.nextToken()
+		if i := p.parseInterfaceShape(); i != nil {
+			i.Gates = gates
+			tree.Interfaces = append(tree.Interfaces, i)
+		} else {
+			p.synchronize(topLevelKeywords, false)
+		}
+	case token.KEYWORD_WORLD:
+		p.nextToken()
+		if w := p.parseWorldShape(); w != nil {
+			w.Gates = gates
+			tree.Worlds = append(tree.Worlds, w)
+		} else {
+			p.synchronize(topLevelKeywords, false)
+		}
+	case token.KEYWORD_USE:
+		p.nextToken()
+		if u := p.parseTopUseShape(); u != nil {
+			u.Gates = gates
+			tree.Uses = append(tree.Uses, u)
+			p.optionalSemicolon()
+		} else {
+			p.synchronize(topLevelKeywords, false)
+		}
+	case token.KEYWORD_PACKAGE:
+		if len(gates) > 0 {
+			p.errorf(gates[0].Span, ERROR_INVALID_GATE, "packages can't be feature gated")
+		}
+
+		p.nextToken()
+		pkg := p.parsePackageShape()
+		switch {
+		case pkg == nil:
+			p.synchronize(topLevelKeywords, false)
+		case p.peekToken.Type == token.OP_BRACKET_CURLY_LEFT:
+			p.nextToken()
+			if p.parsePackageBlock(pkg) {
+				tree.Packages = append(tree.Packages, pkg)
 			}
-			tree.Interfaces = append(tree.Interfaces, p.parseInterfaceShape())
-		case token.KEYWORD_WORLD:
-			if !p.expectNextToken(token.KEYWORD_WORLD) {
-				return nil
+		default:
+			if p.peekToken.Type == token.OP_SEMICOLON {
+				p.nextToken()
+				pkg.Span = p.spanFrom(pkg.Identifier.Token)
 			}
-			tree.World = p.parseWorldShape()
-		case token.KEYWORD_USE:
-			if !p.expectNextToken(token.KEYWORD_USE) {
-				return nil
+			p.declarePackage(tree, pkg)
+		}
+	default:
+		p.errorf(p.peekToken.Span(), ERROR_UNEXPECTED_TOKEN, "invalid token: %s [%s]", p.peekToken.Literal, p.peekToken.Type)
+		p.synchronize(topLevelKeywords, false)
+	}
+}
+
+var (
+	// keywords starting an item at the top level of a file
+	topLevelKeywords = map[token.TokenType]bool{
+		token.KEYWORD_INTERFACE: true,
+		token.KEYWORD_WORLD:     true,
+		token.KEYWORD_USE:       true,
+		token.KEYWORD_PACKAGE:   true,
+	}
+
+	// keywords starting an item in an interface, world or resource body
+	itemKeywords = map[token.TokenType]bool{
+		token.KEYWORD_CONSTRUCTOR: true,
+		token.KEYWORD_USE:         true,
+		token.KEYWORD_TYPE:        true,
+		token.KEYWORD_RECORD:      true,
+		token.KEYWORD_VARIANT:     true,
+		token.KEYWORD_ENUM:        true,
+		token.KEYWORD_FLAGS:       true,
+		token.KEYWORD_UNION:       true,
+		token.KEYWORD_RESOURCE:    true,
+		token.KEYWORD_IMPORT:      true,
+		token.KEYWORD_EXPORT:      true,
+		token.KEYWORD_INCLUDE:     true,
+
+		// a body missing its closing brace ends at the next top level item,
+		// see closeBody
+		token.KEYWORD_INTERFACE: true,
+		token.KEYWORD_WORLD:     true,
+		token.KEYWORD_PACKAGE:   true,
+	}
+
+	// keywords that can't start an item in an interface, world or resource
+	// body and so end a body missing its closing brace
+	bodyEnds = map[token.TokenType]bool{
+		token.KEYWORD_INTERFACE: true,
+		token.KEYWORD_WORLD:     true,
+		token.KEYWORD_PACKAGE:   true,
+	}
+)
+
+// declarePackage sets the package declaration of tree. A file declares its
+// package once, later declarations are reported and dropped.
+func (p *Parser) declarePackage(tree *ast.AST, pkg *ast.Package) {
+	first := tree.Package
+	if first == nil {
+		tree.Package = pkg
+		return
+	}
+
+	var d *diagnostic.Diagnostic
+	if name := packageName(pkg); name == packageName(first) {
+		d = p.errorf(pkg.Span, ERROR_PACKAGE_MISMATCH, "package %s is declared more than once", name)
+	} else {
+		d = p.errorf(pkg.Span, ERROR_PACKAGE_MISMATCH, "package %s does not match %s", name, packageName(first))
+	}
+	d.Notes = append(d.Notes, diagnostic.Note{
+		Span:    first.Span,
+		Message: "package first declared here",
+	})
+}
+
+// synchronize skips tokens after a syntax error until the peek token is one
+// of keywords, an unmatched `}`, a `@` starting a line or EOF, so parsing can
+// resume with the next item. Blocks in braces are skipped as a whole. With
+// funcItems set, an identifier starting a new line is taken to start a
+// function item.
+func (p *Parser) synchronize(keywords map[token.TokenType]bool, funcItems bool) {
+	depth := 0
+
+	for p.peekToken.Type != token.END_OF_FILE {
+		switch p.peekToken.Type {
+		case token.OP_BRACKET_CURLY_LEFT:
+			depth++
+		case token.OP_BRACKET_CURLY_RIGHT:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case token.IDENTIFIER:
+			if depth == 0 && funcItems && p.peekToken.Pos.Line > p.curToken.End.Line {
+				return
 			}
-			tree.Uses = append(tree.Uses, p.parseTopUseShape())
-		case token.KEYWORD_PACKAGE:
-			if !p.expectNextToken(token.KEYWORD_PACKAGE) {
-				return nil
+		case token.OP_AT:
+			// feature gates starting a line precede the next item
+			if depth == 0 && p.peekToken.Pos.Line > p.curToken.End.Line {
+				return
 			}
-			tree.Package = p.parsePackageShape()
 		default:
-			p.errors = errors.Join(p.errors, errors.New("invalid token: "+p.peekToken.Literal+" ["+string(p.peekToken.Type)+"]"))
+			if depth == 0 && keywords[p.peekToken.Type] {
+				return
+			}
+		}
+
+		p.nextToken()
+	}
+}
+
+// parseBody calls parseItem for each item of an interface or world body
+// until the closing `}`, the start of the next top level item or EOF, which
+// is left as the peek token. After an item fails the parser synchronizes on
+// the next item. Every iteration consumes at least one token, so the loop
+// terminates on any input.
+func (p *Parser) parseBody(parseItem func() bool) {
+	for !p.peekIsEnd(token.OP_BRACKET_CURLY_RIGHT) && !bodyEnds[p.peekToken.Type] {
+		offset := p.peekToken.Pos.Offset
+
+		if parseItem() {
+			p.optionalSemicolon()
+		} else {
+			p.synchronize(itemKeywords, true)
+		}
+
+		if p.peekToken.Pos.Offset == offset && !p.peekIsEnd(token.OP_BRACKET_CURLY_RIGHT) && !bodyEnds[p.peekToken.Type] {
 			p.nextToken()
 		}
 	}
+}
 
-	return tree
+// optionalSemicolon consumes the `;` ending an item in the newer WIT
+// syntax, as used by bundled dependencies, if there is one.
+func (p *Parser) optionalSemicolon() {
+	if p.peekToken.Type == token.OP_SEMICOLON {
+		p.nextToken()
+	}
+}
+
+// closeBody consumes the `}` closing a body after parseBody. A body cut
+// short by the next top level item is reported once, however many bodies
+// it leaves open, and counts as closed so the partial node is kept and the
+// next item is still parsed. It returns false after reporting any other
+// missing `}`.
+func (p *Parser) closeBody() bool {
+	if p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
+		return true
+	}
+
+	if !bodyEnds[p.peekToken.Type] {
+		p.expectError(token.OP_BRACKET_CURLY_RIGHT)
+		return false
+	}
+
+	if p.unclosed != p.peekToken.Pos {
+		p.unclosed = p.peekToken.Pos
+		p.expectError(token.OP_BRACKET_CURLY_RIGHT)
+	}
+	return true
+}
+
+// parseList calls parseElem for each element of a comma separated list
+// closed by end, allowing a trailing comma, and consumes the closing token.
+// When an element fails, or is not followed by a comma or end, the parser
+// skips to the next comma so the remaining elements are still parsed. It
+// reports whether the list was closed by end; if not, the error has been
+// reported.
+func (p *Parser) parseList(end token.TokenType, parseElem func() bool) bool {
+	for !p.peekIsEnd(end) {
+		if !parseElem() {
+			p.skipTo(token.OP_COMMA, end)
+		} else if p.peekToken.Type != token.OP_COMMA && p.peekToken.Type != end {
+			p.expectError(token.OP_COMMA)
+			p.skipTo(token.OP_COMMA, end)
+		}
+
+		if p.peekToken.Type != token.OP_COMMA {
+			break
+		}
+		p.nextToken()
+	}
+
+	if p.peekToken.Type == end {
+		p.nextToken()
+		return true
+	}
+
+	if p.peekToken.Type == token.END_OF_FILE {
+		p.expectError(end)
+	}
+	return false
+}
+
+// skipTo skips tokens until the peek token is one of stops outside of any
+// nested brackets, an unmatched closing bracket, a keyword starting an item
+// or EOF.
+func (p *Parser) skipTo(stops ...token.TokenType) {
+	depth := 0
+
+	for p.peekToken.Type != token.END_OF_FILE {
+		if depth == 0 {
+			if itemKeywords[p.peekToken.Type] {
+				return
+			}
+			for _, t := range stops {
+				if p.peekToken.Type == t {
+					return
+				}
+			}
+		}
+
+		switch p.peekToken.Type {
+		case token.OP_BRACKET_CURLY_LEFT, token.OP_BRACKET_PAREN_LEFT, token.OP_BRACKET_ANGLE_LEFT:
+			depth++
+		case token.OP_BRACKET_CURLY_RIGHT, token.OP_BRACKET_PAREN_RIGHT, token.OP_BRACKET_ANGLE_RIGHT:
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
+
+		p.nextToken()
+	}
+}
+
+// peekIsEnd reports whether the peek token closes a body or list, either
+// because it is end or because the input is exhausted.
+func (p *Parser) peekIsEnd(end token.TokenType) bool {
+	return p.peekToken.Type == end || p.peekToken.Type == token.END_OF_FILE
+}
+
+// errorf records an error diagnostic covering span.
+func (p *Parser) errorf(span token.Span, code string, format string, args ...any) *diagnostic.Diagnostic {
+	return p.errors.Addf(span, code, format, args...)
+}
+
+// expectError records that the peek token is not of the expected type. For
+// punctuation a fix inserting the missing token is suggested.
+func (p *Parser) expectError(t token.TokenType) {
+	d := p.errorf(p.peekToken.Span(), ERROR_EXPECTED_TOKEN, "expected %s, got %s", t, p.peekToken.Type)
+
+	if r := []rune(string(t)); !unicode.IsLetter(r[0]) {
+		d.Fixes = append(d.Fixes, diagnostic.Fix{
+			Message:     fmt.Sprintf("insert `%s`", t),
+			Span:        token.Span{Start: p.curToken.End, End: p.curToken.End},
+			Replacement: string(t),
+		})
+	}
+}
+
+// spanFrom returns the span from the start of tok up to the end of the
+// current token.
+func (p *Parser) spanFrom(tok token.Token) token.Span {
+	return token.Span{Start: tok.Pos, End: p.curToken.End}
 }
 
 func (p *Parser) expectNextToken(t token.TokenType) bool {
@@ -84,6 +414,5 @@ func (p *Parser) expectNextToken(t token.TokenType) bool {
 		return true
 	}
 
-	// p.errors = errors.Join(p.errors, WRONG_NEXT_TYPE_ERROR(string(p.peekToken.Type), string(t)))
 	return false
 }
@@ -1,12 +1,17 @@
 package parser
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"text/template"
 
 	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/diagnostic"
 	"github.com/jordan-rash/go-wit/lexer"
 	"github.com/jordan-rash/go-wit/token"
 
@@ -115,6 +120,8 @@ var (
 	}{
 		{"package wasi:derp", "wasi", "derp", ""},
 		{"package wasi:derp@0.1.0", "wasi", "derp", "0.1.0"},
+		{"package wasi:derp@0.2.0-rc-2023-11-10+build.5", "wasi", "derp", "0.2.0-rc-2023-11-10+build.5"},
+		{"package wasi:derp@1.0.0-alpha.1", "wasi", "derp", "1.0.0-alpha.1"},
 	}
 	funcTests = []struct {
 		Input              string
@@ -127,6 +134,39 @@ var (
 	}
 )
 
+func versionString(v *ast.Version) string {
+	if v == nil {
+		return ""
+	}
+	return v.String()
+}
+
+// typeToken returns the type of the first token of ty, which is the keyword
+// of any type but a named one.
+func typeToken(ty ast.Type) string {
+	switch v := ty.(type) {
+	case *ast.Primitive:
+		return string(v.Token.Type)
+	case *ast.Named:
+		return string(v.Name.Token.Type)
+	case *ast.List:
+		return string(v.Token.Type)
+	case *ast.Option:
+		return string(v.Token.Type)
+	case *ast.Result:
+		return string(v.Token.Type)
+	case *ast.Tuple:
+		return string(v.Token.Type)
+	case *ast.Handle:
+		return string(v.Token.Type)
+	case *ast.Future:
+		return string(v.Token.Type)
+	case *ast.Stream:
+		return string(v.Token.Type)
+	}
+	return ""
+}
+
 func TestParsePingPong(t *testing.T) {
 	input := `package jordan-rash:pingpong@0.1.0
 
@@ -138,7 +178,7 @@ interface pingpong {
   use types.{pong}
   ping: func() -> pong
 
-  log: func(string) -> string
+  log: func(msg: string) -> string
 }
 
 world ping-pong {
@@ -157,19 +197,19 @@ world ping-pong {
 	p := New(lexer.NewLexer(input))
 	a := p.Parse()
 
-	assert.NoError(t, p.Errors())
+	assert.NoError(t, p.Errors().Err())
 	assert.NotNil(t, a)
 
-	pkg, ok := a.Package.(*ast.Package)
-	if assert.True(t, ok) {
+	pkg := a.Package
+	if assert.NotNil(t, pkg) {
 		assert.Equal(t, "jordan-rash", pkg.Namespace)
 		assert.Equal(t, "pingpong", pkg.Name)
-		assert.Equal(t, "0.1.0", pkg.SemVer)
+		assert.Equal(t, "0.1.0", versionString(pkg.Version))
 	}
 
 	for idx, i := range a.Interfaces {
-		ii, ok := i.(*ast.Interface)
-		if assert.True(t, ok) {
+		ii := i
+		if assert.NotNil(t, ii) {
 			assert.Equal(t, iFaceAnswers[idx].name, ii.Name)
 			assert.Len(t, ii.Items.UseItems, iFaceAnswers[idx].useLength)
 			assert.Len(t, ii.Items.TypedefItems, iFaceAnswers[idx].tDefsLength)
@@ -195,33 +235,33 @@ func TestRootShapes(t *testing.T) {
 		p := New(lexer.NewLexer(tt.input))
 
 		tree := p.Parse()
-		assert.NoError(t, p.Errors())
+		assert.NoError(t, p.Errors().Err())
 
 		assert.NotNil(t, tree)
 
 		switch tt.expectedType {
 		case token.KEYWORD_INTERFACE:
-			i, ok := tree.Interfaces[0].(*ast.Interface)
+			i := tree.Interfaces[0]
 
-			assert.True(t, ok)
+			assert.NotNil(t, i)
 			assert.Equal(t, "derp", i.Name)
 		case token.KEYWORD_WORLD:
-			w, ok := tree.World.(*ast.World)
+			w := tree.World("derp")
 
-			assert.True(t, ok)
+			assert.NotNil(t, w)
 			assert.Equal(t, "derp", w.Name)
 		case token.KEYWORD_USE:
-			u, ok := tree.Uses[0].(*ast.Use)
+			u := tree.Uses[0]
 
-			assert.True(t, ok)
+			assert.NotNil(t, u)
 			assert.Equal(t, "derp", u.Identifier.TokenLiteral())
 		case token.KEYWORD_PACKAGE:
-			p, ok := tree.Package.(*ast.Package)
+			p := tree.Package
 
-			assert.True(t, ok)
+			assert.NotNil(t, p)
 			assert.Equal(t, "jordan-rash", p.Namespace)
 			assert.Equal(t, "pingpong", p.Name)
-			assert.Equal(t, "0.1.0", p.SemVer)
+			assert.Equal(t, "0.1.0", versionString(p.Version))
 		}
 	}
 }
@@ -239,7 +279,7 @@ func TestNestedInterfaceShapes(t *testing.T) {
 
 		tree := p.Parse()
 		assert.NotNil(t, tree)
-		assert.NoError(t, p.Errors(), i)
+		assert.NoError(t, p.Errors().Err(), i)
 
 		assert.NotNil(t, tree)
 		assert.Len(t, tree.Interfaces, 1)
@@ -254,7 +294,7 @@ func TestNestedInterfaceShapes(t *testing.T) {
 
 		tree := p.Parse()
 		assert.NotNil(t, tree)
-		assert.NoError(t, p.Errors())
+		assert.NoError(t, p.Errors().Err())
 
 		assert.NotNil(t, tree)
 		assert.Len(t, tree.Interfaces, 1)
@@ -275,12 +315,12 @@ func TestNestedWorldShapes(t *testing.T) {
 		tree := p.Parse()
 
 		assert.NotNil(t, tree)
-		assert.NoError(t, p.Errors())
+		assert.NoError(t, p.Errors().Err())
 
-		assert.NotNil(t, tree.World)
+		assert.Len(t, tree.Worlds, 1)
 
-		w, ok := tree.World.(*ast.World)
-		assert.True(t, ok)
+		w := tree.World("foo")
+		assert.NotNil(t, w)
 		assert.Equal(t, "foo", w.Name)
 		assert.Len(t, w.ExportItems, 1)
 	}
@@ -292,17 +332,14 @@ func TestTypeShape(t *testing.T) {
 		t.Log("TESTING ->", tt.Input)
 		for p.peekToken.Type != token.END_OF_FILE {
 			assert.True(t, p.expectNextToken(token.KEYWORD_TYPE))
-			e := p.parseTypeShape()
-			assert.NoError(t, p.Errors())
-
-			ts, ok := e.(*ast.TypeShape)
-			assert.True(t, ok, i)
+			ts := p.parseTypeShape()
+			assert.NoError(t, p.Errors().Err())
+			if !assert.NotNil(t, ts, i) {
+				break
+			}
 
 			assert.Equal(t, tt.expectedType, string(ts.Token.Type))
-
-			ty, ok := ts.Value.(*ast.Ty)
-			assert.True(t, ok, i)
-			assert.Equal(t, tt.expectedValueType, string(ty.Token.Type))
+			assert.Equal(t, tt.expectedValueType, typeToken(ts.Value), i)
 		}
 	}
 }
@@ -314,13 +351,10 @@ func TestTypeListShape(t *testing.T) {
 		for p.peekToken.Type != token.END_OF_FILE {
 			assert.True(t, p.expectNextToken(token.KEYWORD_LIST))
 			tempType := p.parseListShape()
-			assert.NoError(t, p.Errors(), i)
-
-			x, ok := tempType.Value.(*ast.Ty)
-			assert.True(t, ok)
+			assert.NoError(t, p.Errors().Err(), i)
 
-			assert.Equal(t, token.KEYWORD_LIST, string(tempType.Name.Token.Type))
-			assert.Equal(t, tt.expectedValueType, string(x.Token.Type))
+			assert.Equal(t, token.KEYWORD_LIST, string(tempType.Token.Type))
+			assert.Equal(t, tt.expectedValueType, typeToken(tempType.Elem))
 		}
 	}
 }
@@ -333,13 +367,10 @@ func TestTypeOptionShape(t *testing.T) {
 		for p.peekToken.Type != token.END_OF_FILE {
 			assert.True(t, p.expectNextToken(token.KEYWORD_OPTION))
 			tempType := p.parseOptionShape()
-			assert.NoError(t, p.Errors())
+			assert.NoError(t, p.Errors().Err())
 
-			x, ok := tempType.Value.(*ast.Ty)
-			assert.True(t, ok)
-
-			assert.Equal(t, token.KEYWORD_OPTION, string(tempType.Name.Token.Type))
-			assert.Equal(t, tt.expectedValueType, string(x.Token.Type))
+			assert.Equal(t, token.KEYWORD_OPTION, string(tempType.Token.Type))
+			assert.Equal(t, tt.expectedValueType, typeToken(tempType.Elem))
 		}
 	}
 }
@@ -351,13 +382,11 @@ func TestTypeTupleShape(t *testing.T) {
 		for p.peekToken.Type != token.END_OF_FILE {
 			assert.True(t, p.expectNextToken(token.KEYWORD_TUPLE))
 			tempType := p.parseTupleShape()
-			assert.NoError(t, p.Errors())
+			assert.NoError(t, p.Errors().Err())
 
-			assert.Equal(t, token.KEYWORD_TUPLE, string(tempType.Name.Token.Type))
-			for i, x := range tempType.Value {
-				xx, ok := x.(*ast.Ty)
-				assert.True(t, ok)
-				assert.Equal(t, tt.expectedsValue[i], string(xx.Token.Type))
+			assert.Equal(t, token.KEYWORD_TUPLE, string(tempType.Token.Type))
+			for i, x := range tempType.Elems {
+				assert.Equal(t, tt.expectedsValue[i], typeToken(x))
 			}
 
 		}
@@ -371,42 +400,108 @@ func TestTypeResultShape(t *testing.T) {
 		for p.peekToken.Type != token.END_OF_FILE {
 			assert.True(t, p.expectNextToken(token.KEYWORD_RESULT))
 			tempType := p.parseResultShape()
-			assert.NoError(t, p.Errors())
-			assert.Equal(t, token.KEYWORD_RESULT, string(tempType.Name.Token.Type))
+			assert.NoError(t, p.Errors().Err())
+			assert.Equal(t, token.KEYWORD_RESULT, string(tempType.Token.Type))
 
-			if tempType.OkValue != nil {
-				ty, ok := tempType.OkValue.(*ast.Ty)
-				assert.True(t, ok)
-				assert.Equal(t, tt.expectedOkValue, string(ty.Token.Type))
-			} else {
-				assert.Nil(t, tempType.OkValue)
+			if tempType.Ok != nil {
+				assert.Equal(t, tt.expectedOkValue, typeToken(tempType.Ok))
 			}
 
-			if tempType.ErrValue != nil {
-				ty, ok := tempType.ErrValue.(*ast.Ty)
-				assert.True(t, ok)
-				assert.Equal(t, tt.expectedErrValue, string(ty.Token.Type))
-			} else {
-				assert.Nil(t, tempType.ErrValue)
+			if tempType.Err != nil {
+				assert.Equal(t, tt.expectedErrValue, typeToken(tempType.Err))
 			}
 		}
 	}
 }
 
+func TestHandleShape(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedKind     string
+		expectedResource string
+	}{
+		{"type a = own<blob>", token.KEYWORD_OWN, "blob"},
+		{"type b = borrow<input-stream>", token.KEYWORD_BORROW, "input-stream"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.NewLexer(tt.input))
+		t.Log("TESTING ->", tt.input)
+
+		assert.True(t, p.expectNextToken(token.KEYWORD_TYPE))
+		ts := p.parseTypeShape()
+		assert.NoError(t, p.Errors().Err())
+
+		hs, ok := ts.Value.(*ast.Handle)
+		if !assert.True(t, ok) {
+			continue
+		}
+
+		assert.Equal(t, tt.expectedKind, string(hs.Kind))
+		assert.Equal(t, tt.expectedResource, hs.Resource.Value)
+		assert.False(t, hs.Implicit)
+	}
+
+	p := New(lexer.NewLexer("type c = own<>"))
+	assert.True(t, p.expectNextToken(token.KEYWORD_TYPE))
+	p.parseTypeShape()
+	assert.Error(t, p.Errors().Err())
+}
+
+func TestAsyncShapes(t *testing.T) {
+	tests := []struct {
+		input             string
+		expectedType      string
+		expectedValueType string
+	}{
+		{"type a = future", token.KEYWORD_FUTURE, ""},
+		{"type b = future<u32>", token.KEYWORD_FUTURE, token.KEYWORD_U32},
+		{"type c = stream", token.KEYWORD_STREAM, ""},
+		{"type d = stream<list<u8>>", token.KEYWORD_STREAM, token.KEYWORD_LIST},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.NewLexer(tt.input))
+		t.Log("TESTING ->", tt.input)
+
+		assert.True(t, p.expectNextToken(token.KEYWORD_TYPE))
+		ts := p.parseTypeShape()
+		assert.NoError(t, p.Errors().Err())
+		assert.Equal(t, tt.expectedType, typeToken(ts.Value))
+
+		var value ast.Type
+		switch v := ts.Value.(type) {
+		case *ast.Future:
+			value = v.Elem
+		case *ast.Stream:
+			value = v.Elem
+		default:
+			t.Fatalf("unexpected type %T", ts.Value)
+		}
+
+		if tt.expectedValueType == "" {
+			assert.Nil(t, value)
+			continue
+		}
+
+		assert.Equal(t, tt.expectedValueType, typeToken(value))
+	}
+}
+
 func TestTypePackageShape(t *testing.T) {
 	for _, tt := range packageTests {
 		p := New(lexer.NewLexer(tt.input))
 		t.Log("TESTING ->", tt.input)
 		for p.peekToken.Type != token.END_OF_FILE {
 			tree := p.Parse()
-			assert.NoError(t, p.Errors())
+			assert.NoError(t, p.Errors().Err())
 
-			pkg, ok := tree.Package.(*ast.Package)
-			assert.True(t, ok)
+			pkg := tree.Package
+			assert.NotNil(t, pkg)
 
 			assert.Equal(t, tt.namespace, pkg.Namespace)
 			assert.Equal(t, tt.name, pkg.Name)
-			assert.Equal(t, tt.version, pkg.SemVer)
+			assert.Equal(t, tt.version, versionString(pkg.Version))
 
 			assert.Equal(t, token.KEYWORD_PACKAGE, string(strings.ToUpper(pkg.Identifier.TokenLiteral())))
 		}
@@ -420,7 +515,7 @@ func TestExportShape(t *testing.T) {
 		for p.peekToken.Type != token.END_OF_FILE {
 			assert.True(t, p.expectNextToken(token.KEYWORD_EXPORT))
 			es := p.parseExportStatement()
-			assert.NoError(t, p.Errors())
+			assert.NoError(t, p.Errors().Err())
 			assert.NotNil(t, es)
 			assert.Equal(t, tt.expectedType, string(es.Token.Type))
 			assert.Equal(t, tt.expectedName, es.Name.Value)
@@ -434,35 +529,84 @@ func TestFuncShape(t *testing.T) {
 		t.Log("TESTING ->", tt.Input)
 		for p.peekToken.Type != token.END_OF_FILE {
 			tempType := p.parseFuncItem()
-			assert.NoError(t, p.Errors())
+			assert.NoError(t, p.Errors().Err())
 
-			ft, ok := tempType.Value.(*ast.FuncType)
-			assert.True(t, ok)
+			ft := tempType.Func
 
 			assert.Equal(t, tt.name, tempType.Name.Token.Literal, i)
 			assert.Len(t, *ft.ParamList, len(tt.expectedParamList))
 			assert.Len(t, *ft.ResultList, len(tt.expectedResultList))
 
 			for i, param := range *ft.ParamList {
-				ty, ok := param.(*ast.Ty)
-				assert.True(t, ok)
-				assert.Equal(t, tt.expectedParamList[i], string(ty.Token.Type))
+				assert.Equal(t, tt.expectedParamList[i], typeToken(param.Type))
 			}
 
 			for i, res := range *ft.ResultList {
-				ty, ok := res.(*ast.Ty)
-				assert.True(t, ok)
-				assert.Equal(t, tt.expectedResultList[i], string(ty.Token.Type))
+				assert.Equal(t, tt.expectedResultList[i], typeToken(res.Type))
+			}
+		}
+	}
+}
+
+func TestFuncResults(t *testing.T) {
+	p := New(lexer.NewFileLexer("derp.wit", `interface foo {
+  none: func()
+  empty: func() -> ()
+  single: func() -> u32
+  named: func(x: u8) -> (a: u32, b: list<string>,)
+}`))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	funcs := tree.Interfaces[0].Items.FuncItems
+	if !assert.Len(t, funcs, 4) {
+		return
+	}
+
+	ft := funcs[0].Func
+	assert.Nil(t, ft.ResultList)
+
+	ft = funcs[1].Func
+	if assert.NotNil(t, ft.ResultList) {
+		assert.Empty(t, *ft.ResultList)
+	}
+	assert.Equal(t, "derp.wit:3:22", ft.End().String())
+
+	ft = funcs[2].Func
+	if assert.NotNil(t, ft.ResultList) && assert.Len(t, *ft.ResultList, 1) {
+		res := (*ft.ResultList)[0]
+		assert.Nil(t, res.Name)
+		assert.Equal(t, token.KEYWORD_U32, typeToken(res.Type))
+	}
+
+	ft = funcs[3].Func
+	if assert.NotNil(t, ft.ResultList) && assert.Len(t, *ft.ResultList, 2) {
+		for i, name := range []string{"a", "b"} {
+			nt := (*ft.ResultList)[i]
+			if assert.NotNil(t, nt.Name) {
+				assert.Equal(t, name, nt.Name.Value)
+				assert.NotNil(t, nt.Type)
 			}
 		}
 	}
+	assert.Equal(t, "derp.wit:5:51", ft.End().String())
+
+	p = New(lexer.NewFileLexer("derp.wit", "interface foo {\n  bad: func() -> (a: u32 b: u32)\n  good: func()\n}"))
+	tree = p.Parse()
+	if assert.Len(t, p.Errors(), 1) {
+		assert.Equal(t, "derp.wit:2:26", p.Errors()[0].Span.Start.String())
+	}
+	funcs = tree.Interfaces[0].Items.FuncItems
+	if assert.Len(t, funcs, 2) {
+		assert.Equal(t, "good", funcs[1].Name.Value)
+	}
 }
 
 func TestResourceShape(t *testing.T) {
 	resourceTest := struct {
 		input         string
 		name          string
-		expectedValue []ast.Expression
+		expectedValue []ast.Type
 	}{
 		input: `resource blob {
     constructor(init: list<u8>)
@@ -471,7 +615,7 @@ func TestResourceShape(t *testing.T) {
     merge: static func(lhs: borrow<blob>, rhs: borrow<blob>) -> blob
   }`,
 		name:          "blob",
-		expectedValue: []ast.Expression{},
+		expectedValue: []ast.Type{},
 	}
 
 	p := New(lexer.NewLexer(resourceTest.input))
@@ -480,12 +624,101 @@ func TestResourceShape(t *testing.T) {
 	for p.peekToken.Type != token.END_OF_FILE {
 		assert.True(t, p.expectNextToken(token.KEYWORD_RESOURCE))
 		tempType := p.parseResourceShape()
-		assert.NoError(t, p.Errors())
+		assert.NoError(t, p.Errors().Err())
 
 		assert.Equal(t, "blob", tempType.Name.Token.Literal)
 		assert.Equal(t, "RESOURCE", string(tempType.Token.Type))
 
-		assert.Len(t, tempType.Value, 4)
+		if assert.NotNil(t, tempType.Constructor) {
+			ft := tempType.Constructor.Func
+			assert.Len(t, *ft.ParamList, 1)
+			assert.Nil(t, ft.ResultList)
+		}
+
+		if assert.Len(t, tempType.Methods, 2) {
+			assert.Equal(t, "write", tempType.Methods[0].Name.Value)
+			assert.Equal(t, "read", tempType.Methods[1].Name.Value)
+			assert.False(t, tempType.Methods[0].Static)
+		}
+
+		if assert.Len(t, tempType.StaticFuncs, 1) {
+			assert.Equal(t, "merge", tempType.StaticFuncs[0].Name.Value)
+			assert.True(t, tempType.StaticFuncs[0].Static)
+		}
+	}
+}
+
+func TestResourceItems(t *testing.T) {
+	input := `interface files {
+  resource error;
+  resource descriptor {
+    /// Opens a file
+    @since(version = 0.2.0)
+    constructor(path: string) -> result<descriptor, error>
+
+    /// Reads from the file
+    read: func(len: u64) -> result<list<u8>>
+    @unstable(feature = sync)
+    sync: func()
+    open-at: static func(path: string) -> descriptor
+  }
+  resource plain
+}`
+
+	p := New(lexer.NewFileLexer("derp.wit", input))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	typedefs := tree.Interfaces[0].Items.TypedefItems
+	if !assert.Len(t, typedefs, 3) {
+		return
+	}
+
+	rs := typedefs[0].Kind.(*ast.ResourceShape)
+	assert.Equal(t, "error", rs.Name.Value)
+	assert.Nil(t, rs.Constructor)
+	assert.Empty(t, rs.Methods)
+	assert.Equal(t, "derp.wit:2:18", rs.End().String())
+
+	rs = typedefs[1].Kind.(*ast.ResourceShape)
+	if assert.NotNil(t, rs.Constructor) {
+		ctor := rs.Constructor
+		assert.Equal(t, "constructor", ctor.Name.Value)
+		assert.Equal(t, "Opens a file", ctor.Docs.Text())
+		assert.Len(t, ctor.Gates, 1)
+		ft := ctor.Func
+		if assert.NotNil(t, ft.ResultList) {
+			assert.Len(t, *ft.ResultList, 1)
+		}
+	}
+	if assert.Len(t, rs.Methods, 2) {
+		assert.Equal(t, "Reads from the file", rs.Methods[0].Docs.Text())
+		assert.Equal(t, "sync", rs.Methods[1].Gates.Feature())
+	}
+	if assert.Len(t, rs.StaticFuncs, 1) {
+		assert.Equal(t, "open-at", rs.StaticFuncs[0].Name.Value)
+	}
+
+	rs = typedefs[2].Kind.(*ast.ResourceShape)
+	assert.Equal(t, "plain", rs.Name.Value)
+
+	p = New(lexer.NewFileLexer("derp.wit", `interface files {
+  resource a {
+    constructor() -> u32
+    constructor()
+    constructor()
+  }
+}`))
+	p.Parse()
+
+	errs := p.Errors()
+	if assert.Len(t, errs, 3) {
+		for _, d := range errs {
+			assert.Equal(t, ERROR_INVALID_CTOR, d.Code)
+		}
+		assert.Equal(t, "derp.wit:3:22", errs[0].Span.Start.String())
+		assert.Equal(t, "derp.wit:4:5", errs[1].Span.Start.String())
+		assert.Equal(t, "derp.wit:5:5", errs[2].Span.Start.String())
 	}
 }
 
@@ -512,17 +745,15 @@ func TestEnumShape(t *testing.T) {
 	for p.peekToken.Type != token.END_OF_FILE {
 		assert.True(t, p.expectNextToken(token.KEYWORD_ENUM))
 		tempType := p.parseEnumShape()
-		assert.NoError(t, p.Errors())
+		assert.NoError(t, p.Errors().Err())
 
 		assert.Equal(t, enumTest.expectedName, tempType.Name.Token.Literal)
 		assert.Equal(t, token.KEYWORD_ENUM, string(tempType.Token.Type))
 
-		assert.Len(t, tempType.Value, len(enumTest.expectedValue))
+		assert.Len(t, tempType.Cases, len(enumTest.expectedValue))
 
-		for i, v := range tempType.Value {
-			ty, ok := v.(*ast.Ty)
-			assert.True(t, ok)
-			assert.Equal(t, enumTest.expectedValue[i], ty.Value.TokenLiteral())
+		for i, v := range tempType.Cases {
+			assert.Equal(t, enumTest.expectedValue[i], v.Identifier.Value)
 		}
 	}
 }
@@ -549,17 +780,15 @@ func TestFlagShape(t *testing.T) {
 	for p.peekToken.Type != token.END_OF_FILE {
 		assert.True(t, p.expectNextToken(token.KEYWORD_FLAGS))
 		tempType := p.parseFlagShape()
-		assert.NoError(t, p.Errors())
+		assert.NoError(t, p.Errors().Err())
 
 		assert.Equal(t, flagTest.expectedName, tempType.Name.Token.Literal)
 		assert.Equal(t, token.KEYWORD_FLAGS, string(tempType.Token.Type))
 
-		assert.Len(t, tempType.Value, len(flagTest.expectedValue))
+		assert.Len(t, tempType.Flags, len(flagTest.expectedValue))
 
-		for i, v := range tempType.Value {
-			ty, ok := v.(*ast.Ty)
-			assert.True(t, ok)
-			assert.Equal(t, flagTest.expectedValue[i], ty.Value.TokenLiteral())
+		for i, v := range tempType.Flags {
+			assert.Equal(t, flagTest.expectedValue[i], v.Identifier.Value)
 		}
 	}
 }
@@ -588,17 +817,16 @@ func TestUnionShape(t *testing.T) {
 	for p.peekToken.Type != token.END_OF_FILE {
 		assert.True(t, p.expectNextToken(token.KEYWORD_UNION))
 		tempType := p.parseUnionShape()
-		assert.NoError(t, p.Errors())
+		assert.NoError(t, p.Errors().Err())
 
 		assert.Equal(t, unionTest.expectedName, tempType.Name.Token.Literal)
 		assert.Equal(t, token.KEYWORD_UNION, string(tempType.Token.Type))
 
-		assert.Len(t, tempType.Value, len(unionTest.expectedValue))
+		assert.Len(t, tempType.Cases, len(unionTest.expectedValue))
 
-		for i, v := range tempType.Value {
-			ty, ok := v.(*ast.Ty)
-			assert.True(t, ok)
-			assert.Equal(t, unionTest.expectedValue[i].Literal, ty.Value.TokenLiteral())
+		for i, v := range tempType.Cases {
+			assert.Equal(t, string(unionTest.expectedValue[i].Type), typeToken(v))
+			assert.Equal(t, unionTest.expectedValue[i].Literal, v.TokenLiteral())
 		}
 	}
 }
@@ -609,7 +837,7 @@ func TestVariantShape(t *testing.T) {
 		expectedName  string
 		expectedValue []struct {
 			Identifier string
-			Ty         *ast.Ty
+			Ty         ast.Type
 		}
 	}{
 		input: `variant filter {
@@ -621,11 +849,11 @@ func TestVariantShape(t *testing.T) {
 		expectedName: "filter",
 		expectedValue: []struct {
 			Identifier string
-			Ty         *ast.Ty
+			Ty         ast.Type
 		}{
 			{"all", nil},
 			{"none", nil},
-			{"some", &ast.Ty{Token: token.Token{Type: token.KEYWORD_LIST, Literal: "list"}}},
+			{"some", &ast.List{Token: token.Token{Type: token.KEYWORD_LIST, Literal: "list"}}},
 		},
 	}
 
@@ -635,17 +863,1115 @@ func TestVariantShape(t *testing.T) {
 	for p.peekToken.Type != token.END_OF_FILE {
 		assert.True(t, p.expectNextToken(token.KEYWORD_VARIANT))
 		tempType := p.parseVariantShape()
-		assert.NoError(t, p.Errors())
+		assert.NoError(t, p.Errors().Err())
 
 		assert.Equal(t, variantTest.expectedName, tempType.Identifier.Token.Literal)
 		assert.Equal(t, token.KEYWORD_VARIANT, string(tempType.Token.Type))
 
-		assert.Len(t, tempType.Value, len(variantTest.expectedValue))
+		assert.Len(t, tempType.Cases, len(variantTest.expectedValue))
 
-		for i, v := range tempType.Value {
+		for i, v := range tempType.Cases {
 			if variantTest.expectedValue[i].Ty == nil {
-				assert.Nil(t, v.Value)
+				assert.Nil(t, v.Type)
+			} else {
+				assert.Equal(t, typeToken(variantTest.expectedValue[i].Ty), typeToken(v.Type))
 			}
 		}
 	}
 }
+
+func TestNodePositions(t *testing.T) {
+	input := `package wasi:derp@0.1.0
+
+interface foo {
+  type bar = list<u8>
+  baz: func() -> string
+}
+
+world host {
+  export foo
+}
+`
+
+	p := New(lexer.NewFileLexer("derp.wit", input))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	pkg := tree.Package
+	if assert.NotNil(t, pkg) {
+		assert.Equal(t, "derp.wit:1:1", pkg.Pos().String())
+		assert.Equal(t, "derp.wit:1:24", pkg.End().String())
+	}
+
+	iFace := tree.Interfaces[0]
+	if assert.NotNil(t, iFace) {
+		assert.Equal(t, "derp.wit:3:1", iFace.Pos().String())
+		assert.Equal(t, "derp.wit:6:2", iFace.End().String())
+
+		td := iFace.Items.TypedefItems[0]
+		assert.Equal(t, "derp.wit:4:3", td.Pos().String())
+		assert.Equal(t, "derp.wit:4:22", td.End().String())
+
+		fn := iFace.Items.FuncItems[0]
+		assert.Equal(t, "derp.wit:5:3", fn.Pos().String())
+		assert.Equal(t, "derp.wit:5:24", fn.End().String())
+		assert.Equal(t, "derp.wit:5:3", fn.Name.Pos().String())
+	}
+
+	w := tree.World("host")
+	if assert.NotNil(t, w) {
+		assert.Equal(t, "derp.wit:8:1", w.Pos().String())
+		assert.Equal(t, "derp.wit:10:2", w.End().String())
+		assert.Equal(t, "derp.wit:9:3", w.ExportItems[0].Pos().String())
+		assert.Equal(t, "derp.wit:9:13", w.ExportItems[0].End().String())
+	}
+}
+
+func TestParserDiagnostics(t *testing.T) {
+	input := `interface foo {
+  type bar = list<u8
+}`
+
+	p := New(lexer.NewFileLexer("derp.wit", input))
+	p.Parse()
+
+	errs := p.Errors()
+	if assert.Len(t, errs, 1) {
+		d := errs[0]
+		assert.Equal(t, diagnostic.Error, d.Severity)
+		assert.Equal(t, ERROR_EXPECTED_TOKEN, d.Code)
+		assert.Equal(t, "derp.wit:3:1", d.Span.Start.String())
+		if assert.Len(t, d.Fixes, 1) {
+			assert.Equal(t, ">", d.Fixes[0].Replacement)
+			assert.Equal(t, "derp.wit:2:21", d.Fixes[0].Span.Start.String())
+		}
+	}
+
+	assert.EqualError(t, errs.Err(), "derp.wit:3:1: error[P0002]: expected >, got }")
+}
+
+func TestParseString(t *testing.T) {
+	tree, err := ParseString("derp.wit", "package foo:bar\ninterface baz {}\n")
+	assert.NoError(t, err)
+	if assert.NotNil(t, tree) {
+		assert.Equal(t, "derp.wit", tree.Filename)
+		assert.Len(t, tree.Interfaces, 1)
+	}
+
+	tree, err = ParseString("derp.wit", "interface baz {\n  type a = list<u8\n}")
+	assert.NotNil(t, tree)
+	if errs, ok := err.(diagnostic.DiagnosticList); assert.True(t, ok) && assert.Len(t, errs, 1) {
+		assert.Equal(t, "derp.wit:3:1", errs[0].Span.Start.String())
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	tree, err := ParseFile("../cmd/simple/pingpong.wit")
+	assert.NoError(t, err)
+	if assert.NotNil(t, tree) {
+		assert.Equal(t, "../cmd/simple/pingpong.wit", tree.Filename)
+		assert.NotNil(t, tree.Package)
+	}
+
+	_, err = ParseFile("../cmd/simple/derp.wit")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"wit/b.wit":      {Data: []byte("interface b {}")},
+		"wit/a.wit":      {Data: []byte("package foo:bar\ninterface a {}")},
+		"wit/c.wit":      {Data: []byte("interface c {\n  d: func(\n}")},
+		"wit/README.md":  {Data: []byte("# not wit")},
+		"wit/deps/d.wit": {Data: []byte("interface d {}")},
+	}
+
+	pkg, err := ParseFS(fsys, "wit")
+	if assert.NotNil(t, pkg) && assert.Len(t, pkg.Files, 3) {
+		assert.Equal(t, "wit/a.wit", pkg.Files[0].Filename)
+		assert.Equal(t, "wit/b.wit", pkg.Files[1].Filename)
+		assert.Equal(t, "wit/c.wit", pkg.Files[2].Filename)
+		assert.Equal(t, "bar", pkg.Package.Name)
+		assert.NotNil(t, pkg.Interface("b"))
+	}
+	if errs, ok := err.(diagnostic.DiagnosticList); assert.True(t, ok) && assert.NotEmpty(t, errs) {
+		assert.Equal(t, "wit/c.wit", errs[0].Span.Start.Filename)
+	}
+
+	_, err = ParseFS(fsys, "derp")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"a.wit": "package foo:bar\ninterface a {}",
+		"b.wit": "world b {}",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkg, err := ParseDir(dir)
+	assert.NoError(t, err)
+	if assert.NotNil(t, pkg) && assert.Len(t, pkg.Files, 2) {
+		assert.Equal(t, filepath.Join(dir, "a.wit"), pkg.Files[0].Filename)
+		assert.NotNil(t, pkg.World("b"))
+		assert.Len(t, pkg.Interfaces, 1)
+	}
+}
+
+func TestMergeFiles(t *testing.T) {
+	parse := func(name, src string) *ast.AST {
+		tree, err := ParseString(name, src)
+		assert.NoError(t, err)
+		return tree
+	}
+
+	pkg, err := MergeFiles(
+		parse("a.wit", "package foo:bar@0.1.0\ninterface a {}\nworld w {}"),
+		parse("b.wit", "interface b {}"),
+		parse("c.wit", "package foo:bar@0.1.0\nworld c {}"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1.0", versionString(pkg.Package.Version))
+	assert.Len(t, pkg.Interfaces, 2)
+	assert.Len(t, pkg.Worlds, 2)
+
+	pkg, err = MergeFiles(
+		parse("a.wit", "package foo:bar@0.1.0\ninterface a {}"),
+		parse("b.wit", "package foo:baz\n\ninterface b {}\nworld a {}"),
+	)
+	assert.Len(t, pkg.Files, 2)
+
+	errs, ok := err.(diagnostic.DiagnosticList)
+	if assert.True(t, ok) && assert.Len(t, errs, 2) {
+		assert.Equal(t, ERROR_PACKAGE_MISMATCH, errs[0].Code)
+		assert.Equal(t, "b.wit:1:1", errs[0].Span.Start.String())
+		assert.Equal(t, "package foo:baz does not match foo:bar@0.1.0", errs[0].Message)
+		if assert.Len(t, errs[0].Notes, 1) {
+			assert.Equal(t, "a.wit:1:1", errs[0].Notes[0].Span.Start.String())
+		}
+
+		assert.Equal(t, ERROR_DUPLICATE_NAME, errs[1].Code)
+		assert.Equal(t, "b.wit:4:1", errs[1].Span.Start.String())
+		if assert.Len(t, errs[1].Notes, 1) {
+			assert.Equal(t, "a.wit:2:1", errs[1].Notes[0].Span.Start.String())
+		}
+	}
+}
+
+func TestMergeNestedPackages(t *testing.T) {
+	parse := func(name, src string) *ast.AST {
+		tree, err := ParseString(name, src)
+		assert.NoError(t, err)
+		return tree
+	}
+
+	pkg, err := MergeFiles(
+		parse("a.wit", "package foo:bar;\n\npackage foo:dep {\n  interface a {}\n}"),
+		parse("b.wit", "package foo:dep {\n  interface b {}\n  world a {}\n}\n\npackage foo:other {\n  interface a {}\n}"),
+		parse("c.wit", "package foo:dep {\n  interface b {}\n}"),
+	)
+
+	// blocks of the same package are merged, whichever file they are in
+	if assert.Len(t, pkg.Packages, 2) {
+		dep := pkg.Packages[0]
+		assert.Equal(t, "dep", dep.Name)
+		assert.Len(t, dep.Interfaces, 3)
+		assert.Len(t, dep.Worlds, 1)
+
+		other := pkg.Packages[1]
+		assert.Equal(t, "other", other.Name)
+		assert.Len(t, other.Interfaces, 1)
+	}
+	assert.Empty(t, pkg.Interfaces)
+
+	// each package has a namespace of its own
+	errs, ok := err.(diagnostic.DiagnosticList)
+	if assert.True(t, ok) && assert.Len(t, errs, 2) {
+		assert.Equal(t, ERROR_DUPLICATE_NAME, errs[0].Code)
+		assert.Equal(t, "b.wit:3:3", errs[0].Span.Start.String())
+		if assert.Len(t, errs[0].Notes, 1) {
+			assert.Equal(t, "a.wit:4:3", errs[0].Notes[0].Span.Start.String())
+		}
+
+		assert.Equal(t, ERROR_DUPLICATE_NAME, errs[1].Code)
+		assert.Equal(t, "c.wit:2:3", errs[1].Span.Start.String())
+		if assert.Len(t, errs[1].Notes, 1) {
+			assert.Equal(t, "b.wit:2:3", errs[1].Notes[0].Span.Start.String())
+		}
+	}
+}
+
+func TestParserRecovery(t *testing.T) {
+	input := `package wasi:derp
+
+interface foo {
+  record point {
+    x: u32,
+    y: ,
+    z: u32,
+  }
+
+  bad: func(a: u32 -> string
+
+  enum color { red green, blue }
+}
+
+interface 42 {
+}
+
+world bar {
+  import baz: 17
+  export foo
+}
+`
+
+	p := New(lexer.NewFileLexer("derp.wit", input))
+	tree := p.Parse()
+
+	var lines []int
+	for _, d := range p.Errors() {
+		lines = append(lines, d.Span.Start.Line)
+	}
+	assert.Equal(t, []int{6, 10, 12, 15, 19}, lines)
+
+	if assert.Len(t, tree.Interfaces, 1) {
+		foo := tree.Interfaces[0]
+		assert.Len(t, foo.Items.TypedefItems, 2)
+
+		point := foo.Items.TypedefItems[0].Kind.(*ast.RecordShape)
+		assert.Len(t, point.Fields, 2)
+	}
+
+	w := tree.World("bar")
+	if assert.NotNil(t, w) {
+		assert.Len(t, w.ImportItems, 0)
+		assert.Len(t, w.ExportItems, 1)
+	}
+}
+
+func TestParserRecoveryUnclosedBody(t *testing.T) {
+	input := `interface a {
+  f: func()
+
+  resource r {
+    m: func()
+
+interface b { g: func() }
+world w { export b }
+
+package local:nested {
+  world v {
+    import b
+
+package local:other {}
+`
+
+	p := New(lexer.NewFileLexer("derp.wit", input))
+	tree := p.Parse()
+
+	// each item ending open bodies is reported once
+	if assert.Len(t, p.Errors(), 2) {
+		for i, line := range []int{7, 14} {
+			d := p.Errors()[i]
+			assert.Equal(t, ERROR_EXPECTED_TOKEN, d.Code)
+			assert.Equal(t, line, d.Span.Start.Line)
+			assert.Contains(t, d.Message, "expected }")
+		}
+	}
+
+	// the partial items are kept and the following items are parsed
+	if assert.Len(t, tree.Interfaces, 2) {
+		a := tree.Interfaces[0]
+		assert.Equal(t, "f", a.Items.FuncItems[0].Name.Value)
+		r := a.Items.TypedefItems[0].Kind.(*ast.ResourceShape)
+		assert.Equal(t, "m", r.Methods[0].Name.Value)
+		assert.Equal(t, "b", tree.Interfaces[1].Name)
+	}
+	if w := tree.World("w"); assert.NotNil(t, w) {
+		assert.Len(t, w.ExportItems, 1)
+	}
+	if assert.Len(t, tree.Packages, 2) {
+		assert.Len(t, tree.Packages[0].Worlds[0].ImportItems, 1)
+		assert.Equal(t, "other", tree.Packages[1].Name)
+	}
+}
+
+func TestExplicitIdentifiers(t *testing.T) {
+	input := `interface %interface {
+  record %record {
+    %type: u32,
+    bad--name: string,
+  }
+}`
+
+	p := New(lexer.NewFileLexer("derp.wit", input))
+	tree := p.Parse()
+
+	if assert.Len(t, p.Errors(), 1) {
+		assert.Equal(t, lexer.ERROR_INVALID_IDENTIFIER, p.Errors()[0].Code)
+		assert.Equal(t, "derp.wit:4:5", p.Errors()[0].Span.Start.String())
+	}
+
+	if assert.Len(t, tree.Interfaces, 1) {
+		iFace := tree.Interfaces[0]
+		assert.Equal(t, "interface", iFace.Name)
+
+		rs := iFace.Items.TypedefItems[0].Kind.(*ast.RecordShape)
+		assert.Equal(t, "record", rs.Identifier.Value)
+		if assert.Len(t, rs.Fields, 2) {
+			field := rs.Fields[0]
+			assert.Equal(t, "type", field.Identifier.Value)
+			assert.True(t, field.Identifier.Token.Explicit)
+		}
+	}
+}
+
+func TestParserTerminates(t *testing.T) {
+	b, err := os.ReadFile("../cmd/simple/core.wit")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// every prefix of a valid file is a plausible half typed file, all of
+	// them must parse to completion
+	input := string(b)
+	for i := range input {
+		p := New(lexer.NewLexer(input[:i]))
+		assert.NotNil(t, p.Parse())
+	}
+
+	for _, input := range []string{"}", "{", "interface", "interface foo", "world w { import", "record r {", ")))", "use a:b/c@", "package"} {
+		p := New(lexer.NewLexer(input))
+		assert.NotNil(t, p.Parse(), input)
+		assert.Error(t, p.Errors().Err(), input)
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	input := `/// The derp package
+package wasi:derp
+
+// not documentation
+/// Types used by derp.
+///
+/// More details.
+interface types {
+  /**
+   * A pong.
+   */
+  type pong = string
+
+  /// Ping the server.
+  ping: func() -> pong
+
+  // plain comment
+  pong: func() -> pong
+
+  enum level {
+    /// Everything
+    trace,
+    error,
+  }
+
+  flags mode {
+    /// May read
+    @since(version = 0.2.0)
+    read,
+    write,
+  }
+}
+
+/* the world */
+world host {
+  /// Exported types
+  export types
+}
+`
+
+	p := New(lexer.NewLexer(input))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	pkg := tree.Package
+	if assert.NotNil(t, pkg) {
+		assert.Equal(t, "The derp package", pkg.Docs.Text())
+	}
+
+	iFace := tree.Interfaces[0]
+	if assert.NotNil(t, iFace) {
+		assert.Equal(t, "Types used by derp.\n\nMore details.", iFace.Docs.Text())
+		assert.Equal(t, "A pong.", iFace.Items.TypedefItems[0].Docs.Text())
+		assert.Equal(t, "Ping the server.", iFace.Items.FuncItems[0].Docs.Text())
+		assert.Nil(t, iFace.Items.FuncItems[1].Docs)
+
+		level := iFace.Items.TypedefItems[1].Kind.(*ast.EnumShape)
+		assert.Equal(t, "Everything", level.Cases[0].Docs.Text())
+		assert.Nil(t, level.Cases[1].Docs)
+
+		mode := iFace.Items.TypedefItems[2].Kind.(*ast.FlagShape)
+		assert.Equal(t, "May read", mode.Flags[0].Docs.Text())
+		assert.Len(t, mode.Flags[0].Gates, 1)
+		assert.Nil(t, mode.Flags[1].Docs)
+	}
+
+	w := tree.World("host")
+	if assert.NotNil(t, w) {
+		assert.Nil(t, w.Docs)
+		assert.Equal(t, "Exported types", w.ExportItems[0].Docs.Text())
+	}
+}
+
+func TestInterfaceTypedefs(t *testing.T) {
+	// the types interface from cmd/simple/core.wit
+	input := `interface types {
+  type link-settings =          list<tuple<string, string>>
+  type host-env-values =        list<tuple<string, string>>
+  type trace-context =          list<tuple<string, string>>
+  type actor-links =            list<link-definition>
+  type cluster-issuers =        list<cluster-issuer-key>
+  type cluster-issuer-key =     string
+  type capability-contract-id = string
+  type blob =                   list<u8>
+  type public-key =             string
+
+  record link-definition {
+    actor-id:     string,
+    provider-id:  string,
+    link-name:    string,
+    contract-id:  string,
+    values:       option<link-settings>,
+  }
+
+  record health-check-response {
+    healthy: bool,
+    message: string,
+  }
+
+  record invocation-response {
+    msg:            blob,
+    invocation-id:  string,
+    error:          option<string>,
+    content-length: u64,
+  }
+
+  variant wasmcloud-entity {
+    actor(public-key),
+    provider(provider-identifier),
+  }
+
+  enum color { red, green, blue }
+
+  flags permissions { read, write }
+
+  union configuration { string, list<string> }
+
+  resource blob-store {
+    constructor(init: list<u8>)
+    read: func(n: u32) -> list<u8>
+  }
+}`
+
+	expected := []struct {
+		name string
+		kind any
+		len  int
+	}{
+		{"link-settings", &ast.TypeShape{}, 0},
+		{"host-env-values", &ast.TypeShape{}, 0},
+		{"trace-context", &ast.TypeShape{}, 0},
+		{"actor-links", &ast.TypeShape{}, 0},
+		{"cluster-issuers", &ast.TypeShape{}, 0},
+		{"cluster-issuer-key", &ast.TypeShape{}, 0},
+		{"capability-contract-id", &ast.TypeShape{}, 0},
+		{"blob", &ast.TypeShape{}, 0},
+		{"public-key", &ast.TypeShape{}, 0},
+		{"link-definition", &ast.RecordShape{}, 5},
+		{"health-check-response", &ast.RecordShape{}, 2},
+		{"invocation-response", &ast.RecordShape{}, 4},
+		{"wasmcloud-entity", &ast.VariantShape{}, 2},
+		{"color", &ast.EnumShape{}, 3},
+		{"permissions", &ast.FlagShape{}, 2},
+		{"configuration", &ast.UnionShape{}, 2},
+		{"blob-store", &ast.ResourceShape{}, 1},
+	}
+
+	p := New(lexer.NewLexer(input))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	iFace := tree.Interfaces[0]
+	if !assert.NotNil(t, iFace) || !assert.Len(t, iFace.Items.TypedefItems, len(expected)) {
+		return
+	}
+
+	for i, tt := range expected {
+		td := iFace.Items.TypedefItems[i]
+
+		assert.Equal(t, tt.name, td.Name.TokenLiteral(), i)
+		assert.IsType(t, tt.kind, td.Kind, i)
+
+		switch v := td.Kind.(type) {
+		case *ast.RecordShape:
+			assert.Len(t, v.Fields, tt.len, i)
+		case *ast.VariantShape:
+			assert.Len(t, v.Cases, tt.len, i)
+		case *ast.EnumShape:
+			assert.Len(t, v.Cases, tt.len, i)
+		case *ast.FlagShape:
+			assert.Len(t, v.Flags, tt.len, i)
+		case *ast.UnionShape:
+			assert.Len(t, v.Cases, tt.len, i)
+		case *ast.ResourceShape:
+			assert.Len(t, v.Methods, tt.len, i)
+			assert.NotNil(t, v.Constructor, i)
+		}
+	}
+}
+
+func TestWorldItems(t *testing.T) {
+	input := `world proxy {
+  use types.{request}
+
+  type headers = list<tuple<string, string>>
+  record pair { key: string, value: string }
+
+  include wasi:cli/imports@0.2.0 with { environment as env, exit as quit }
+  include base
+
+  import print: func(msg: string)
+  import wasi:logging/logging
+  import store: interface {
+    get: func() -> string
+    type key = string
+  }
+
+  export handler: interface {
+    handle: func() -> u32
+  }
+  export run: func()
+}`
+
+	p := New(lexer.NewLexer(input))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	w := tree.World("proxy")
+	if !assert.NotNil(t, w) {
+		return
+	}
+
+	assert.Equal(t, "proxy", w.Name)
+
+	assert.Len(t, w.UseItems, 1)
+
+	if assert.Len(t, w.TypedefItems, 2) {
+		assert.Equal(t, "headers", w.TypedefItems[0].Name.Value)
+		assert.IsType(t, &ast.RecordShape{}, w.TypedefItems[1].Kind)
+	}
+
+	if assert.Len(t, w.IncludeItems, 2) {
+		assert.Equal(t, "wasi:cli/imports@0.2.0", w.IncludeItems[0].Name.Value)
+		assert.Equal(t, []string{"environment", "exit"}, []string{w.IncludeItems[0].With[0].Value, w.IncludeItems[0].With[1].Value})
+		assert.Equal(t, []string{"env", "quit"}, []string{w.IncludeItems[0].With[0].Alias, w.IncludeItems[0].With[1].Alias})
+		assert.Equal(t, "base", w.IncludeItems[1].Name.Value)
+		assert.Empty(t, w.IncludeItems[1].With)
+
+		// the path is kept structurally
+		if up := w.IncludeItems[0].Path; assert.NotNil(t, up) {
+			assert.Equal(t, "cli", up.Package.Value)
+			assert.Equal(t, "imports", up.Name.Value)
+			assert.Equal(t, "0.2.0", versionString(up.Version))
+		}
+		if up := w.IncludeItems[1].Path; assert.NotNil(t, up) {
+			assert.False(t, up.IsQualified())
+			assert.Same(t, w.IncludeItems[1].Name, up.Name)
+		}
+	}
+
+	if assert.Len(t, w.ImportItems, 3) {
+		assert.Equal(t, "print", w.ImportItems[0].Name.Value)
+		assert.NotNil(t, w.ImportItems[0].Func)
+		assert.Nil(t, w.ImportItems[0].Interface)
+
+		assert.Equal(t, "wasi:logging/logging", w.ImportItems[1].Name.Value)
+		assert.Nil(t, w.ImportItems[1].Func)
+		assert.Nil(t, w.ImportItems[1].Interface)
+		if up := w.ImportItems[1].Path; assert.NotNil(t, up) {
+			assert.Equal(t, "wasi", up.Namespace.Value)
+			assert.Equal(t, "logging", up.Name.Value)
+			assert.Nil(t, up.Version)
+		}
+		assert.Nil(t, w.ImportItems[0].Path)
+
+		assert.Equal(t, "store", w.ImportItems[2].Name.Value)
+		if ii := w.ImportItems[2].Interface; assert.NotNil(t, ii) {
+			assert.Len(t, ii.FuncItems, 1)
+			assert.Len(t, ii.TypedefItems, 1)
+		}
+	}
+
+	if assert.Len(t, w.ExportItems, 2) {
+		if ii := w.ExportItems[0].Interface; assert.NotNil(t, ii) {
+			assert.Len(t, ii.FuncItems, 1)
+		}
+		assert.NotNil(t, w.ExportItems[1].Func)
+	}
+
+	var kinds []string
+	for _, item := range w.Items() {
+		kinds = append(kinds, fmt.Sprintf("%T", item))
+	}
+	assert.Equal(t, []string{
+		"*ast.UseShape",
+		"*ast.TypeDef", "*ast.TypeDef",
+		"*ast.IncludeShape", "*ast.IncludeShape",
+		"*ast.ImportShape", "*ast.ImportShape", "*ast.ImportShape",
+		"*ast.ExportShape", "*ast.ExportShape",
+	}, kinds)
+}
+
+func TestNestedPackages(t *testing.T) {
+	input := `package local:demo;
+
+package wasi:io@0.2.0 {
+  interface streams {
+    resource input-stream {}
+  }
+}
+
+/// Clocks
+package wasi:clocks {
+  interface wall-clock {}
+
+  world imports {
+    import wall-clock
+  }
+}
+
+world demo {}
+`
+
+	p := New(lexer.NewFileLexer("derp.wit", input))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	if pkg := tree.Package; assert.NotNil(t, pkg) {
+		assert.Equal(t, "demo", pkg.Name)
+		assert.Equal(t, "derp.wit:1:20", pkg.End().String())
+	}
+	assert.NotNil(t, tree.World("demo"))
+	assert.Empty(t, tree.Interfaces)
+
+	if assert.Len(t, tree.Packages, 2) {
+		io := tree.Packages[0]
+		assert.Equal(t, "wasi", io.Namespace)
+		assert.Equal(t, "io", io.Name)
+		assert.Equal(t, "0.2.0", versionString(io.Version))
+		assert.Len(t, io.Interfaces, 1)
+		assert.Empty(t, io.Worlds)
+		assert.Equal(t, "derp.wit:3:1", io.Pos().String())
+		assert.Equal(t, "derp.wit:7:2", io.End().String())
+
+		clocks := tree.Packages[1]
+		assert.Equal(t, "Clocks", clocks.Docs.Text())
+		assert.Len(t, clocks.Interfaces, 1)
+		if assert.Len(t, clocks.Worlds, 1) {
+			assert.Equal(t, "imports", clocks.Worlds[0].Name)
+		}
+	}
+
+	p = New(lexer.NewFileLexer("derp.wit", "package a:b {\n  record foo {}\n  interface bar {}\n}\nworld baz {}"))
+	tree = p.Parse()
+	if assert.Len(t, p.Errors(), 1) {
+		assert.Equal(t, "derp.wit:2:3", p.Errors()[0].Span.Start.String())
+	}
+	if assert.Len(t, tree.Packages, 1) {
+		assert.Len(t, tree.Packages[0].Interfaces, 1)
+	}
+	assert.Len(t, tree.Worlds, 1)
+
+	// bundled dependencies end items with `;`
+	input = `use wasi:io/streams;
+package a:b@1.0.0 {
+  interface i {
+    use types.{t};
+    type u = t;
+    f: func();
+    resource r {
+      constructor();
+      m: func();
+    }
+  }
+  world w {
+    import i;
+    export f: func();
+    include other;
+  }
+}`
+	p = New(lexer.NewFileLexer("derp.wit", input))
+	tree = p.Parse()
+	assert.NoError(t, p.Errors().Err())
+	assert.Len(t, tree.Uses, 1)
+	if assert.Len(t, tree.Packages, 1) {
+		i := tree.Packages[0].Interfaces[0]
+		assert.Len(t, i.Items.UseItems, 1)
+		assert.Len(t, i.Items.TypedefItems, 2)
+		assert.Len(t, i.Items.FuncItems, 1)
+		assert.Len(t, i.Items.TypedefItems[1].Kind.(*ast.ResourceShape).Methods, 1)
+
+		w := tree.Packages[0].Worlds[0]
+		assert.Len(t, w.Items(), 3)
+	}
+}
+
+func TestFeatureGates(t *testing.T) {
+	input := `@since(version = 0.2.0)
+interface clocks {
+  /// Current time
+  @since(version = 0.2.0)
+  @deprecated(version = 0.2.2)
+  now: func() -> u64
+
+  @unstable(feature = fancy-time)
+  record instant {
+    seconds: u64,
+    @unstable(feature = nanos)
+    nanos: u32,
+  }
+
+  variant precision {
+    coarse,
+    @since(version = 0.2.1, feature = fine)
+    fine(u32),
+  }
+
+  enum unit {
+    @since(version = 0.2.0)
+    seconds,
+    @unstable(feature = nanos)
+    nanos,
+  }
+
+  flags clock {
+    wall,
+    @deprecated(version = 0.2.2)
+    monotonic,
+  }
+}
+
+@unstable(feature = imports)
+world imports {
+  @since(version = 0.2.0)
+  import clocks
+}
+`
+
+	p := New(lexer.NewFileLexer("derp.wit", input))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	iFace := tree.Interfaces[0]
+	if !assert.NotNil(t, iFace) {
+		return
+	}
+
+	if assert.Len(t, iFace.Gates, 1) {
+		g := iFace.Gates[0]
+		assert.Equal(t, ast.GATE_SINCE, g.Name.Value)
+		assert.Equal(t, "0.2.0", versionString(g.Version))
+		assert.Nil(t, g.Feature)
+		assert.Equal(t, "derp.wit:1:1", g.Pos().String())
+		assert.Equal(t, "derp.wit:1:24", g.End().String())
+	}
+
+	if assert.Len(t, iFace.Items.FuncItems, 1) {
+		fs := iFace.Items.FuncItems[0]
+		assert.Len(t, fs.Gates, 2)
+		assert.Equal(t, "0.2.0", versionString(fs.Gates.Since()))
+		assert.Equal(t, "0.2.2", versionString(fs.Gates.Deprecated()))
+		assert.Equal(t, "", fs.Gates.Feature())
+		assert.Equal(t, "Current time", fs.Docs.Text())
+	}
+
+	if assert.Len(t, iFace.Items.TypedefItems, 4) {
+		record := iFace.Items.TypedefItems[0]
+		assert.Equal(t, "fancy-time", record.Gates.Feature())
+
+		rs := record.Kind.(*ast.RecordShape)
+		if assert.Len(t, rs.Fields, 2) {
+			assert.Empty(t, rs.Fields[0].Gates)
+			assert.Equal(t, "nanos", rs.Fields[1].Gates.Feature())
+		}
+
+		vs := iFace.Items.TypedefItems[1].Kind.(*ast.VariantShape)
+		if assert.Len(t, vs.Cases, 2) {
+			assert.Empty(t, vs.Cases[0].Gates)
+			assert.Equal(t, "fine", vs.Cases[1].Identifier.Value)
+			assert.Equal(t, "0.2.1", versionString(vs.Cases[1].Gates.Since()))
+		}
+
+		es := iFace.Items.TypedefItems[2].Kind.(*ast.EnumShape)
+		if assert.Len(t, es.Cases, 2) {
+			assert.Equal(t, "0.2.0", versionString(es.Cases[0].Gates.Since()))
+			assert.Equal(t, "nanos", es.Cases[1].Gates.Feature())
+			assert.Equal(t, "nanos", es.Cases[1].Identifier.Value)
+		}
+
+		fs := iFace.Items.TypedefItems[3].Kind.(*ast.FlagShape)
+		if assert.Len(t, fs.Flags, 2) {
+			assert.Empty(t, fs.Flags[0].Gates)
+			assert.Equal(t, "0.2.2", versionString(fs.Flags[1].Gates.Deprecated()))
+		}
+	}
+
+	if w := tree.World("imports"); assert.NotNil(t, w) {
+		assert.Equal(t, "imports", w.Gates.Feature())
+		if assert.Len(t, w.ImportItems, 1) {
+			assert.Len(t, w.ImportItems[0].Gates, 1)
+		}
+	}
+}
+
+func TestInvalidFeatureGates(t *testing.T) {
+	input := `interface foo {
+  @since(feature = bar)
+  a: func()
+  @unstable(version = 1.0.0)
+  b: func()
+  @derp(version = 1.0.0)
+  c: func()
+  @since(version = 1.0.0, color = red)
+  d: func()
+  e: func()
+}`
+
+	p := New(lexer.NewFileLexer("derp.wit", input))
+	tree := p.Parse()
+
+	errs := p.Errors()
+	if assert.Len(t, errs, 4) {
+		for _, d := range errs {
+			assert.Equal(t, ERROR_INVALID_GATE, d.Code)
+		}
+		assert.Equal(t, "@since requires a version", errs[0].Message)
+		assert.Equal(t, "@unstable requires a feature", errs[1].Message)
+		assert.Equal(t, "derp.wit:6:4", errs[2].Span.Start.String())
+		assert.Equal(t, "derp.wit:8:27", errs[3].Span.Start.String())
+	}
+
+	iFace := tree.Interfaces[0]
+	if assert.NotEmpty(t, iFace.Items.FuncItems) {
+		assert.Equal(t, "e", iFace.Items.FuncItems[len(iFace.Items.FuncItems)-1].Name.Value)
+	}
+}
+
+func TestMultipleWorlds(t *testing.T) {
+	input := `package wasi:cli
+
+world imports {
+  import environment
+}
+
+world command {
+  include imports
+  export run
+}
+`
+
+	p := New(lexer.NewLexer(input))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	if assert.Len(t, tree.Worlds, 2) {
+		assert.Equal(t, "imports", tree.Worlds[0].Name)
+		assert.Equal(t, "command", tree.Worlds[1].Name)
+	}
+
+	if w := tree.World("command"); assert.NotNil(t, w) {
+		assert.Len(t, w.IncludeItems, 1)
+		assert.Len(t, w.ExportItems, 1)
+	}
+	assert.Nil(t, tree.World("proxy"))
+}
+
+func TestDuplicatePackage(t *testing.T) {
+	_, err := ParseString("derp.wit", "package a:b;\npackage c:d;\npackage a:b;\ninterface i {}")
+
+	errs, ok := err.(diagnostic.DiagnosticList)
+	if assert.True(t, ok) && assert.Len(t, errs, 2) {
+		assert.Equal(t, ERROR_PACKAGE_MISMATCH, errs[0].Code)
+		assert.Equal(t, "derp.wit:2:1", errs[0].Span.Start.String())
+		assert.Equal(t, "package c:d does not match a:b", errs[0].Message)
+		if assert.Len(t, errs[0].Notes, 1) {
+			assert.Equal(t, "derp.wit:1:1", errs[0].Notes[0].Span.Start.String())
+		}
+
+		assert.Equal(t, "package a:b is declared more than once", errs[1].Message)
+	}
+
+	// the first declaration is kept
+	p := New(lexer.NewLexer("package a:b;\npackage c:d;"))
+	tree := p.Parse()
+	assert.Equal(t, "a", tree.Package.Namespace)
+	assert.Equal(t, "b", tree.Package.Name)
+}
+
+func TestWorldUnexpectedToken(t *testing.T) {
+	p := New(lexer.NewLexer("world foo { 42 export bar }"))
+	tree := p.Parse()
+
+	assert.Len(t, p.Errors(), 1)
+
+	w := tree.World("foo")
+	if assert.NotNil(t, w) {
+		assert.Len(t, w.ExportItems, 1)
+	}
+}
+
+func TestUseShape(t *testing.T) {
+	tests := []struct {
+		input   string
+		path    string
+		items   []string
+		aliases []string
+	}{
+		{"use types.{pong}", "types", []string{"pong"}, []string{""}},
+		{"use types.{}", "types", nil, nil},
+		{"use types.{a, b as c,}", "types", []string{"a", "b"}, []string{"", "c"}},
+		{"use wasi:logging/logging.{level}", "wasi:logging/logging", []string{"level"}, []string{""}},
+		{"use wasi:io/streams@0.2.0.{input-stream as in, output-stream}", "wasi:io/streams@0.2.0", []string{"input-stream", "output-stream"}, []string{"in", ""}},
+	}
+
+	for i, tt := range tests {
+		p := New(lexer.NewLexer(tt.input))
+		t.Log("TESTING ->", tt.input)
+
+		assert.True(t, p.expectNextToken(token.KEYWORD_USE))
+		us := p.parseUseShape()
+		assert.NoError(t, p.Errors().Err(), i)
+		if !assert.NotNil(t, us, i) {
+			continue
+		}
+
+		assert.Equal(t, tt.path, us.UseInterface.Path.String(), i)
+		assert.Equal(t, us.UseInterface.Path.Name, us.Name, i)
+		assert.Len(t, us.UseInterface.Items, len(tt.items), i)
+		for j, item := range us.UseInterface.Items {
+			assert.Equal(t, tt.items[j], item.Value, i)
+			assert.Equal(t, tt.aliases[j], item.Alias, i)
+		}
+	}
+}
+
+func TestTopUseShape(t *testing.T) {
+	tests := []struct {
+		input     string
+		namespace string
+		pkg       string
+		name      string
+		version   string
+		alias     string
+		items     []string
+	}{
+		{"use derp.{foo}", "", "", "derp", "", "", []string{"foo"}},
+		{"use types", "", "", "types", "", "", nil},
+		{"use wasi:http/types as http-types", "wasi", "http", "types", "", "http-types", nil},
+		{"use wasi:http/types@0.2.0", "wasi", "http", "types", "0.2.0", "", nil},
+		{"use wasi:http/types@0.2.0-rc.1 as t", "wasi", "http", "types", "0.2.0-rc.1", "t", nil},
+	}
+
+	for i, tt := range tests {
+		p := New(lexer.NewLexer(tt.input))
+		tree := p.Parse()
+		assert.NoError(t, p.Errors().Err(), i)
+
+		if !assert.Len(t, tree.Uses, 1, i) {
+			continue
+		}
+
+		u := tree.Uses[0]
+		if !assert.NotNil(t, u, i) {
+			continue
+		}
+
+		path := u.UseInterface.Path
+		assert.Equal(t, tt.name, path.Name.Value, i)
+		assert.Equal(t, tt.name, u.Identifier.Value, i)
+		assert.Equal(t, tt.alias, u.Identifier.Alias, i)
+		assert.Equal(t, tt.version, versionString(path.Version), i)
+		if tt.pkg == "" {
+			assert.False(t, path.IsQualified(), i)
+		} else {
+			assert.Equal(t, tt.namespace, path.Namespace.Value, i)
+			assert.Equal(t, tt.pkg, path.Package.Value, i)
+		}
+
+		assert.Len(t, u.UseInterface.Items, len(tt.items), i)
+	}
+
+	// a use without names is spelled without `.{}`
+	p := New(lexer.NewLexer("use wasi:io/streams@0.2.0.{}"))
+	tree := p.Parse()
+	assert.Empty(t, tree.Uses)
+	if assert.Len(t, p.Errors(), 1) {
+		assert.Equal(t, ERROR_EXPECTED_TOKEN, p.Errors()[0].Code)
+		assert.Equal(t, 28, p.Errors()[0].Span.Start.Column)
+	}
+}
+
+func TestParseCoreWit(t *testing.T) {
+	b, err := os.ReadFile("../cmd/simple/core.wit")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	p := New(lexer.NewFileLexer("core.wit", string(b)))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	pkg := tree.Package
+	if assert.NotNil(t, pkg) {
+		assert.Equal(t, "wasmcloud", pkg.Namespace)
+		assert.Equal(t, "core", pkg.Name)
+	}
+
+	if !assert.Len(t, tree.Interfaces, 2) {
+		return
+	}
+
+	types := tree.Interfaces[0]
+	if assert.NotNil(t, types) {
+		assert.Equal(t, "types", types.Name)
+		if assert.Len(t, types.Items.UseItems, 1) {
+			assert.Equal(t, "wasi:logging/logging", types.Items.UseItems[0].UseInterface.Path.String())
+		}
+
+		kinds := map[string]int{}
+		for _, td := range types.Items.TypedefItems {
+			kinds[fmt.Sprintf("%T", td.Kind)]++
+		}
+		assert.Equal(t, map[string]int{"*ast.TypeShape": 9, "*ast.RecordShape": 6, "*ast.VariantShape": 1}, kinds)
+	}
+
+	hc := tree.Interfaces[1]
+	if assert.NotNil(t, hc) {
+		assert.Equal(t, "health-check", hc.Name)
+		assert.Len(t, hc.Items.UseItems, 1)
+		assert.Len(t, hc.Items.FuncItems, 1)
+	}
+
+	w := tree.World("wasmcloud-core")
+	if assert.NotNil(t, w) {
+		assert.Equal(t, "wasmcloud-core", w.Name)
+		if assert.Len(t, w.ImportItems, 1) {
+			assert.Equal(t, "wasi:logging/logging", w.ImportItems[0].Name.Value)
+		}
+		if assert.Len(t, w.UseItems, 1) {
+			assert.Len(t, w.UseItems[0].UseInterface.Items, 7)
+		}
+	}
+}
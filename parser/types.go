@@ -1,93 +1,101 @@
 package parser
 
 import (
-	"errors"
-	"fmt"
-
 	"github.com/jordan-rash/go-wit/ast"
 	"github.com/jordan-rash/go-wit/token"
 )
 
-func (p *Parser) parseTypeShape() ast.Expression {
+func (p *Parser) parseTypeShape() *ast.TypeShape {
 	ts := new(ast.TypeShape)
 	ts.Token = p.curToken
 
 	if !p.expectNextToken(token.IDENTIFIER) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected IDENT, got %s", p.peekToken.Type))
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
 	ts.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.expectNextToken(token.OP_EQUAL) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected EQUAL, got %s", p.peekToken.Type))
+		p.expectError(token.OP_EQUAL)
+		return nil
+	}
+
+	ty := p.parseTy()
+	if ty == nil {
 		return nil
 	}
 
-	ts.Value = p.parseTy()
+	ts.Value = ty
+	ts.Span = p.spanFrom(ts.Token)
 
 	return ts
 }
 
-func (p *Parser) parseTy() *ast.Ty {
-	i := new(ast.Ty)
-	i.Token = p.peekToken
-
+// parseTy parses a type. It returns nil, having reported the error, if the
+// type is malformed.
+func (p *Parser) parseTy() ast.Type {
 	switch p.peekToken.Type {
 	case token.KEYWORD_STRING, token.KEYWORD_BOOL, token.KEYWORD_CHAR,
 		token.KEYWORD_FLOAT32, token.KEYWORD_FLOAT64,
 		token.KEYWORD_S8, token.KEYWORD_S16, token.KEYWORD_S32, token.KEYWORD_S64,
-		token.KEYWORD_U8, token.KEYWORD_U16, token.KEYWORD_U32, token.KEYWORD_U64,
-		token.IDENTIFIER:
+		token.KEYWORD_U8, token.KEYWORD_U16, token.KEYWORD_U32, token.KEYWORD_U64:
 
 		p.nextToken()
 
-		i.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	case token.KEYWORD_LIST:
-		if !p.expectNextToken(token.KEYWORD_LIST) {
-			p.errors = errors.Join(p.errors, fmt.Errorf("expected KEYWORD_LIST, got %s", p.peekToken.Type))
-			return nil
-		}
+		return &ast.Primitive{Token: p.curToken}
 
-		c := &ast.TypeShape{Token: p.curToken}
-		c.Value = p.parseListShape()
-		i.Value = c
+	case token.IDENTIFIER:
+		p.nextToken()
 
-	case token.KEYWORD_OPTION:
-		if !p.expectNextToken(token.KEYWORD_OPTION) {
-			p.errors = errors.Join(p.errors, fmt.Errorf("expected KEYWORD_OPTION, got %s", p.peekToken.Type))
-			return nil
-		}
+		return &ast.Named{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
 
-		c := &ast.TypeShape{Token: p.curToken}
-		c.Value = p.parseOptionShape()
-		i.Value = c
+	case token.KEYWORD_LIST, token.KEYWORD_OPTION, token.KEYWORD_RESULT, token.KEYWORD_TUPLE,
+		token.KEYWORD_FUTURE, token.KEYWORD_STREAM, token.KEYWORD_OWN, token.KEYWORD_BORROW:
 
-	case token.KEYWORD_RESULT:
-		if !p.expectNextToken(token.KEYWORD_RESULT) {
-			p.errors = errors.Join(p.errors, fmt.Errorf("expected RESULT_LIST, got %s", p.peekToken.Type))
-			return nil
-		}
+		p.nextToken()
 
-		c := &ast.TypeShape{Token: p.curToken}
-		c.Value = p.parseResultShape()
-		i.Value = c
+		return p.parseTypeConstructor()
 
+	default:
+		p.errorf(p.peekToken.Span(), ERROR_UNEXPECTED_TOKEN, "expected a type, got %s", p.peekToken.Literal)
+		return nil
+	}
+}
+
+// parseTypeConstructor parses a type taking type parameters once its
+// keyword has been consumed.
+func (p *Parser) parseTypeConstructor() ast.Type {
+	switch p.curToken.Type {
+	case token.KEYWORD_LIST:
+		if ls := p.parseListShape(); ls != nil {
+			return ls
+		}
+	case token.KEYWORD_OPTION:
+		if os := p.parseOptionShape(); os != nil {
+			return os
+		}
+	case token.KEYWORD_RESULT:
+		if rs := p.parseResultShape(); rs != nil {
+			return rs
+		}
 	case token.KEYWORD_TUPLE:
-		if !p.expectNextToken(token.KEYWORD_TUPLE) {
-			p.errors = errors.Join(p.errors, fmt.Errorf("expected KEYWORD_TUPLE, got %s", p.peekToken.Type))
-			return nil
+		if ts := p.parseTupleShape(); ts != nil {
+			return ts
+		}
+	case token.KEYWORD_FUTURE:
+		if fs := p.parseFutureShape(); fs != nil {
+			return fs
+		}
+	case token.KEYWORD_STREAM:
+		if ss := p.parseStreamShape(); ss != nil {
+			return ss
+		}
+	case token.KEYWORD_OWN, token.KEYWORD_BORROW:
+		if hs := p.parseHandleShape(); hs != nil {
+			return hs
 		}
-
-		c := &ast.TypeShape{Token: p.curToken}
-		c.Value = p.parseTupleShape()
-		i.Value = c
-
-	default:
-		p.errors = errors.Join(p.errors, fmt.Errorf("unexpected token: %s", p.peekToken.Type))
-		p.nextToken()
-		i.Value = &ast.Identifier{Token: token.Token{Type: token.ILLEGAL, Literal: ""}, Value: p.curToken.Literal}
 	}
 
-	return i
+	return nil
 }
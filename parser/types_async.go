@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/token"
+)
+
+// future ::= 'future'
+//          | 'future' '<' ty '>'
+// stream ::= 'stream'
+//          | 'stream' '<' ty '>'
+
+func (p *Parser) parseFutureShape() *ast.Future {
+	fs := new(ast.Future)
+	fs.Token = p.curToken
+
+	value, ok := p.parseAsyncPayload()
+	if !ok {
+		return nil
+	}
+	fs.Elem = value
+
+	fs.Span = p.spanFrom(fs.Token)
+
+	return fs
+}
+
+func (p *Parser) parseStreamShape() *ast.Stream {
+	ss := new(ast.Stream)
+	ss.Token = p.curToken
+
+	value, ok := p.parseAsyncPayload()
+	if !ok {
+		return nil
+	}
+	ss.Elem = value
+
+	ss.Span = p.spanFrom(ss.Token)
+
+	return ss
+}
+
+// parseAsyncPayload parses the optional `<ty>` following future and stream.
+func (p *Parser) parseAsyncPayload() (ast.Type, bool) {
+	if p.peekToken.Type != token.OP_BRACKET_ANGLE_LEFT {
+		return nil, true
+	}
+	p.nextToken()
+
+	value := p.parseTy()
+	if value == nil {
+		return nil, false
+	}
+
+	if !p.expectNextToken(token.OP_BRACKET_ANGLE_RIGHT) {
+		p.expectError(token.OP_BRACKET_ANGLE_RIGHT)
+		return nil, false
+	}
+
+	return value, true
+}
@@ -1 +1,40 @@
 package parser
+
+import (
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/token"
+)
+
+// handle ::= id
+//          | 'own' '<' id '>'
+//          | 'borrow' '<' id '>'
+//
+// Bare resource names are parsed as identifiers, the resolver turns them
+// into owned handles.
+
+func (p *Parser) parseHandleShape() *ast.Handle {
+	hs := new(ast.Handle)
+	hs.Token = p.curToken
+	hs.Kind = p.curToken.Type
+
+	if !p.expectNextToken(token.OP_BRACKET_ANGLE_LEFT) {
+		p.expectError(token.OP_BRACKET_ANGLE_LEFT)
+		return nil
+	}
+
+	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
+		return nil
+	}
+
+	hs.Resource = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectNextToken(token.OP_BRACKET_ANGLE_RIGHT) {
+		p.expectError(token.OP_BRACKET_ANGLE_RIGHT)
+		return nil
+	}
+
+	hs.Span = p.spanFrom(hs.Token)
+
+	return hs
+}
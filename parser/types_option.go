@@ -1,28 +1,31 @@
 package parser
 
 import (
-	"errors"
-	"fmt"
-
 	"github.com/jordan-rash/go-wit/ast"
 	"github.com/jordan-rash/go-wit/token"
 )
 
-func (p *Parser) parseOptionShape() *ast.OptionShape {
-	os := new(ast.OptionShape)
-	os.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+func (p *Parser) parseOptionShape() *ast.Option {
+	os := new(ast.Option)
+	os.Token = p.curToken
 
 	if !p.expectNextToken(token.OP_BRACKET_ANGLE_LEFT) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected BRACKET_ANGLE_LEFT, got %s", p.peekToken.Type))
+		p.expectError(token.OP_BRACKET_ANGLE_LEFT)
 		return nil
 	}
 
-	os.Value = p.parseTy()
+	ty := p.parseTy()
+	if ty == nil {
+		return nil
+	}
+	os.Elem = ty
 
 	if !p.expectNextToken(token.OP_BRACKET_ANGLE_RIGHT) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected BRACKET_ANGLE_RIGHT, got %s", p.peekToken.Type))
+		p.expectError(token.OP_BRACKET_ANGLE_RIGHT)
 		return nil
 	}
 
+	os.Span = p.spanFrom(os.Token)
+
 	return os
 }
@@ -1,26 +1,24 @@
 package parser
 
 import (
-	"errors"
-	"fmt"
-
 	"github.com/jordan-rash/go-wit/ast"
 	"github.com/jordan-rash/go-wit/token"
 )
 
-func (p *Parser) parseResultShape() *ast.ResultShape {
-	rs := new(ast.ResultShape)
-	rs.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+func (p *Parser) parseResultShape() *ast.Result {
+	rs := new(ast.Result)
+	rs.Token = p.curToken
 
 	// type derp = result
 	if p.peekToken.Type != token.OP_BRACKET_ANGLE_LEFT {
-		rs.OkValue = nil
-		rs.ErrValue = nil
+		rs.Ok = nil
+		rs.Err = nil
+		rs.Span = p.spanFrom(rs.Token)
 		return rs
 	}
 
 	if !p.expectNextToken(token.OP_BRACKET_ANGLE_LEFT) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected BRACKET_ANGLE_LEFT, got %s", p.peekToken.Type))
+		p.expectError(token.OP_BRACKET_ANGLE_LEFT)
 		return nil
 	}
 
@@ -28,36 +26,47 @@ func (p *Parser) parseResultShape() *ast.ResultShape {
 	switch p.peekToken.Type {
 	case token.OP_UNDERSCORE:
 		if !p.expectNextToken(token.OP_UNDERSCORE) {
-			p.errors = errors.Join(p.errors, fmt.Errorf("expected UNDERSCORE, got %s", p.peekToken.Type))
+			p.expectError(token.OP_UNDERSCORE)
 			return nil
 		}
-		rs.OkValue = nil
+		rs.Ok = nil
 	default:
-		rs.OkValue = p.parseTy()
+		ty := p.parseTy()
+		if ty == nil {
+			return nil
+		}
+		rs.Ok = ty
 	}
 
 	if p.peekToken.Type == token.OP_BRACKET_ANGLE_RIGHT {
 		if !p.expectNextToken(token.OP_BRACKET_ANGLE_RIGHT) {
-			p.errors = errors.Join(p.errors, fmt.Errorf("expected KEYWORD_TUPLE, got %s", p.peekToken.Type))
+			p.expectError(token.OP_BRACKET_ANGLE_RIGHT)
 			return nil
 		}
 
-		rs.ErrValue = nil
+		rs.Err = nil
+		rs.Span = p.spanFrom(rs.Token)
 		return rs
 	}
 
 	if !p.expectNextToken(token.OP_COMMA) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected COMMA, got %s", p.peekToken.Type))
+		p.expectError(token.OP_COMMA)
 		return nil
 	}
 
 	// PARSER ERR VALUE ---------------------------
-	rs.ErrValue = p.parseTy()
+	ty := p.parseTy()
+	if ty == nil {
+		return nil
+	}
+	rs.Err = ty
 
 	if !p.expectNextToken(token.OP_BRACKET_ANGLE_RIGHT) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected BRACKET_ANGLE_RIGHT, got %s", p.peekToken.Type))
+		p.expectError(token.OP_BRACKET_ANGLE_RIGHT)
 		return nil
 	}
 
+	rs.Span = p.spanFrom(rs.Token)
+
 	return rs
 }
@@ -1,37 +1,38 @@
 package parser
 
 import (
-	"errors"
-	"fmt"
-
 	"github.com/jordan-rash/go-wit/ast"
 	"github.com/jordan-rash/go-wit/token"
 )
 
-func (p *Parser) parseTupleShape() *ast.TupleShape {
-	ts := new(ast.TupleShape)
-	ts.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+func (p *Parser) parseTupleShape() *ast.Tuple {
+	ts := new(ast.Tuple)
+	ts.Token = p.curToken
 
 	if !p.expectNextToken(token.OP_BRACKET_ANGLE_LEFT) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected BRACKET_ANGLE_LEFT, got %s", p.peekToken.Type))
+		p.expectError(token.OP_BRACKET_ANGLE_LEFT)
 		return nil
 	}
 
-	ts.Value = append(ts.Value, p.parseTy())
-
-	for p.peekToken.Type == token.OP_COMMA {
-		if !p.expectNextToken(token.OP_COMMA) {
-			p.errors = errors.Join(p.errors, fmt.Errorf("expected COMMA, got %s", p.peekToken.Type))
+	for {
+		ty := p.parseTy()
+		if ty == nil {
 			return nil
 		}
+		ts.Elems = append(ts.Elems, ty)
 
-		ts.Value = append(ts.Value, p.parseTy())
+		if p.peekToken.Type != token.OP_COMMA {
+			break
+		}
+		p.nextToken()
 	}
 
 	if !p.expectNextToken(token.OP_BRACKET_ANGLE_RIGHT) {
-		p.errors = errors.Join(p.errors, fmt.Errorf("expected BRACKET_ANGLE_RIGHT, got %s", p.peekToken.Type))
+		p.expectError(token.OP_BRACKET_ANGLE_RIGHT)
 		return nil
 	}
 
+	ts.Span = p.spanFrom(ts.Token)
+
 	return ts
 }
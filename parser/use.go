@@ -1,3 +1,5 @@
+// Parsing use statements
+// Documentation: https://github.com/WebAssembly/component-model/blob/main/design/mvp/WIT.md#item-use
 package parser
 
 import (
@@ -5,33 +7,153 @@ import (
 	"github.com/jordan-rash/go-wit/token"
 )
 
+// toplevel-use-item ::= 'use' use-path ('as' id)?
+//                     | 'use' use-path '.' '{' use-names-list '}'
+
 func (p *Parser) parseTopUseShape() *ast.Use {
 	u := new(ast.Use)
+	u.Docs = p.docs()
+	start := p.curToken
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
+		return nil
+	}
+
+	u.UseInterface.Path = p.parseUsePath()
+	if u.UseInterface.Path == nil {
 		return nil
 	}
 
-	u.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	id := *u.UseInterface.Path.Name
+	u.Identifier = &id
 
-	if !p.expectNextToken(token.OP_PERIOD) {
+	switch p.peekToken.Type {
+	case token.KEYWORD_AS:
+		if !p.expectNextToken(token.KEYWORD_AS) {
+			return nil
+		}
+		if !p.expectNextToken(token.IDENTIFIER) {
+			p.expectError(token.IDENTIFIER)
+			return nil
+		}
+		u.Identifier.Alias = p.curToken.Literal
+	case token.OP_PERIOD:
+		if !p.parseUseNames(&u.UseInterface) {
+			return nil
+		}
+		// `use path` on its own is spelled without `.{}`
+		if len(u.UseInterface.Items) == 0 {
+			p.errorf(p.curToken.Span(), ERROR_EXPECTED_TOKEN, "expected %s, got %s", token.IDENTIFIER, p.curToken.Type)
+			return nil
+		}
+	}
+
+	u.Span = p.spanFrom(start)
+
+	return u
+}
+
+// use-path ::= id
+//            | id ':' id '/' id ('@' valid-semver)?
+
+// parseUsePath parses a use-path whose first identifier is the current token.
+func (p *Parser) parseUsePath() *ast.UsePath {
+	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekToken.Type != token.OP_COLON {
+		return &ast.UsePath{Name: name, Span: name.Token.Span()}
+	}
+
+	if !p.expectNextToken(token.OP_COLON) {
 		return nil
 	}
 
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
+	return p.parseQualifiedPath(name)
+}
+
+// parseQualifiedPath parses the remainder of a fully qualified use-path once
+// its namespace and ':' have been consumed.
+func (p *Parser) parseQualifiedPath(namespace *ast.Identifier) *ast.UsePath {
+	up := &ast.UsePath{Namespace: namespace}
+
+	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
+		return nil
+	}
+	up.Package = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectNextToken(token.OP_SLASH) {
+		p.expectError(token.OP_SLASH)
 		return nil
 	}
 
-	// TODO: this can be 0..n identifiers
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
+	up.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekToken.Type == token.OP_AT {
+		if !p.expectNextToken(token.OP_AT) {
+			return nil
+		}
+		up.Version = p.parseSemVer()
+	}
+
+	up.Span = p.spanFrom(namespace.Token)
+
+	return up
+}
+
+// use-names-list ::= use-names-item
+//                  | use-names-item ',' use-names-list?
+//
+// use-names-item ::= id
+//                  | id 'as' id
+
+// parseUseNames parses the '.' '{' use-names-list '}' following a use-path
+// into ui.Items.
+func (p *Parser) parseUseNames(ui *ast.UseInterface) bool {
+	if !p.expectNextToken(token.OP_PERIOD) {
+		p.expectError(token.OP_PERIOD)
+		return false
+	}
+
+	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
+		p.expectError(token.OP_BRACKET_CURLY_LEFT)
+		return false
+	}
+
+	for p.peekToken.Type != token.OP_BRACKET_CURLY_RIGHT {
+		if !p.expectNextToken(token.IDENTIFIER) {
+			p.expectError(token.IDENTIFIER)
+			return false
+		}
+		item := ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	// stmt.Value = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if p.peekToken.Type == token.KEYWORD_AS {
+			if !p.expectNextToken(token.KEYWORD_AS) {
+				return false
+			}
+			if !p.expectNextToken(token.IDENTIFIER) {
+				p.expectError(token.IDENTIFIER)
+				return false
+			}
+			item.Alias = p.curToken.Literal
+		}
+
+		ui.Items = append(ui.Items, item)
+
+		if !p.expectNextToken(token.OP_COMMA) {
+			break
+		}
+	}
 
 	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
-		return nil
+		p.expectError(token.OP_BRACKET_CURLY_RIGHT)
+		return false
 	}
 
-	return u
+	return true
 }
@@ -1,3 +1,5 @@
+// Parsing worlds
+// Documentation: https://github.com/WebAssembly/component-model/blob/main/design/mvp/WIT.md#wit-worlds
 package parser
 
 import (
@@ -5,40 +7,143 @@ import (
 	"github.com/jordan-rash/go-wit/token"
 )
 
+// world-item ::= 'world' id '{' world-items* '}'
+//
+// world-items ::= export-item | import-item | use-item | typedef-item | include-item
+
 func (p *Parser) parseWorldShape() *ast.World {
 	world := new(ast.World)
 	world.Identifier = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	world.Docs = p.docs()
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 	world.Name = p.curToken.Literal
 
 	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
+		p.expectError(token.OP_BRACKET_CURLY_LEFT)
 		return nil
 	}
 
-	// TODO: NEED TO HANDLE ALL POSSIBLE TYPES
-	for p.peekToken.Type != token.OP_BRACKET_CURLY_RIGHT {
+	p.parseBody(func() bool {
+		gates, ok := p.parseGates()
+		if !ok {
+			return false
+		}
+
 		switch p.peekToken.Type {
 		case token.KEYWORD_EXPORT:
-			if !p.expectNextToken(token.KEYWORD_EXPORT) {
-				return nil
+			p.nextToken()
+			es := p.parseExportStatement()
+			if es == nil {
+				return false
 			}
-			world.ExportItems = append(world.ExportItems, p.parseExportStatement())
+			es.Gates = gates
+			world.ExportItems = append(world.ExportItems, es)
 
 		case token.KEYWORD_IMPORT:
-			if !p.expectNextToken(token.KEYWORD_IMPORT) {
-				return nil
+			p.nextToken()
+			is := p.parseImportStatement()
+			if is == nil {
+				return false
+			}
+			is.Gates = gates
+			world.ImportItems = append(world.ImportItems, is)
+
+		case token.KEYWORD_USE:
+			p.nextToken()
+			us := p.parseUseShape()
+			if us == nil {
+				return false
+			}
+			us.Gates = gates
+			world.UseItems = append(world.UseItems, us)
+
+		case token.KEYWORD_INCLUDE:
+			p.nextToken()
+			is := p.parseIncludeShape()
+			if is == nil {
+				return false
 			}
-			world.ImportItems = append(world.ImportItems, p.parseImportStatement())
+			is.Gates = gates
+			world.IncludeItems = append(world.IncludeItems, is)
 
+		default:
+			td := p.parseTypeDef()
+			if td == nil {
+				return false
+			}
+			td.Gates = gates
+			world.TypedefItems = append(world.TypedefItems, td)
 		}
-	}
 
-	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
+		return true
+	})
+
+	if !p.closeBody() {
 		return nil
 	}
 
+	world.Span = p.spanFrom(world.Identifier.Token)
+
 	return world
 }
+
+// parseQualifiedName parses the remainder of a fully qualified interface
+// name once its namespace and ':' have been consumed. It returns the path
+// along with an identifier spelling it out.
+//
+// id ':' id '/' id ('@' valid-semver)?
+func (p *Parser) parseQualifiedName(namespace *ast.Identifier) (*ast.UsePath, *ast.Identifier) {
+	up := p.parseQualifiedPath(namespace)
+	if up == nil {
+		return nil, nil
+	}
+
+	return up, pathIdentifier(up)
+}
+
+// pathIdentifier returns the name of a local use-path, or an identifier
+// spelling out the whole path of a qualified one.
+func pathIdentifier(up *ast.UsePath) *ast.Identifier {
+	if !up.IsQualified() {
+		return up.Name
+	}
+
+	return &ast.Identifier{
+		Token: token.Token{Type: token.IDENTIFIER, Literal: up.String(), Pos: up.Pos(), End: up.End()},
+		Value: up.String(),
+	}
+}
+
+// localPath returns the use-path of an interface referred to by name.
+func localPath(name *ast.Identifier) *ast.UsePath {
+	return &ast.UsePath{Name: name, Span: name.Token.Span()}
+}
+
+// parseExternType parses the type of a named import or export once the
+// name and ':' have been consumed.
+//
+// extern-type ::= func-type | 'interface' '{' interface-items* '}'
+//
+// Exactly one of the results is set unless the type is malformed.
+func (p *Parser) parseExternType() (*ast.FuncType, *ast.InterfaceItems) {
+	switch p.peekToken.Type {
+	case token.KEYWORD_FUNC:
+		if !p.expectNextToken(token.KEYWORD_FUNC) {
+			return nil, nil
+		}
+		return p.parseFuncType(), nil
+	case token.KEYWORD_INTERFACE:
+		if !p.expectNextToken(token.KEYWORD_INTERFACE) {
+			return nil, nil
+		}
+		return nil, p.parseInterfaceItems()
+	default:
+		p.errorf(p.peekToken.Span(), ERROR_UNEXPECTED_TOKEN, "expected func or interface, got %s", p.peekToken.Literal)
+	}
+
+	return nil, nil
+}
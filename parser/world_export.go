@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"strings"
-
 	"github.com/jordan-rash/go-wit/ast"
 	"github.com/jordan-rash/go-wit/token"
 )
@@ -23,61 +21,33 @@ import (
 func (p *Parser) parseExportStatement() *ast.ExportShape {
 	es := new(ast.ExportShape)
 	es.Token = p.curToken
+	es.Docs = p.docs()
 
 	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
 		return nil
 	}
 
-	es.Name = &ast.Identifier{Value: p.curToken.Literal}
+	es.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if !p.expectNextToken(token.OP_COLON) {
-		es.Value = nil
+		es.Path = localPath(es.Name)
+		es.Span = p.spanFrom(es.Token)
 		return es
 	}
 
 	switch p.peekToken.Type {
-	case token.KEYWORD_FUNC:
-		if !p.expectNextToken(token.KEYWORD_FUNC) {
-			return nil
-		}
-		es.Value = p.parseFuncType()
-	case token.KEYWORD_INTERFACE:
-		if !p.expectNextToken(token.KEYWORD_INTERFACE) {
-			return nil
-		}
-
-		es.Value = p.parseInterfaceItems()
 	case token.IDENTIFIER:
-		sb := strings.Builder{}
-		sb.WriteString(es.Name.Value + ":")
-
-		if !p.expectNextToken(token.IDENTIFIER) {
+		if es.Path, es.Name = p.parseQualifiedName(es.Name); es.Path == nil {
 			return nil
 		}
-		sb.WriteString(p.curToken.Literal)
-
-		if !p.expectNextToken(token.OP_SLASH) {
-			return nil
-		}
-		sb.WriteString(p.curToken.Literal)
-
-		if !p.expectNextToken(token.IDENTIFIER) {
+	default:
+		if es.Func, es.Interface = p.parseExternType(); es.Func == nil && es.Interface == nil {
 			return nil
 		}
-		sb.WriteString(p.curToken.Literal)
-
-		if p.peekToken.Literal == token.OP_AT {
-			if !p.expectNextToken(token.OP_AT) {
-				return nil
-			}
-			sb.WriteString(p.curToken.Literal)
-			sv := p.parseSemVer()
-			sb.WriteString(sv.String())
-		}
-
-		es.Name = &ast.Identifier{Value: sb.String()}
-	default:
 	}
 
+	es.Span = p.spanFrom(es.Token)
+
 	return es
 }
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/token"
+)
+
+// include-item ::= 'include' use-path
+//                | 'include' use-path 'with' '{' include-names-list '}'
+//
+// include-names-list ::= include-names-item
+//                      | include-names-list ',' include-names-item
+//
+// include-names-item ::= id 'as' id
+
+func (p *Parser) parseIncludeShape() *ast.IncludeShape {
+	is := new(ast.IncludeShape)
+	is.Token = p.curToken
+	is.Docs = p.docs()
+
+	if !p.expectNextToken(token.IDENTIFIER) {
+		p.expectError(token.IDENTIFIER)
+		return nil
+	}
+
+	if is.Path = p.parseUsePath(); is.Path == nil {
+		return nil
+	}
+	is.Name = pathIdentifier(is.Path)
+
+	if p.peekToken.Type != token.KEYWORD_WITH {
+		is.Span = p.spanFrom(is.Token)
+		return is
+	}
+
+	if !p.expectNextToken(token.KEYWORD_WITH) {
+		return nil
+	}
+
+	if !p.expectNextToken(token.OP_BRACKET_CURLY_LEFT) {
+		p.expectError(token.OP_BRACKET_CURLY_LEFT)
+		return nil
+	}
+
+	for p.peekToken.Type != token.OP_BRACKET_CURLY_RIGHT {
+		if !p.expectNextToken(token.IDENTIFIER) {
+			p.expectError(token.IDENTIFIER)
+			return nil
+		}
+		name := ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		if !p.expectNextToken(token.KEYWORD_AS) {
+			p.expectError(token.KEYWORD_AS)
+			return nil
+		}
+
+		if !p.expectNextToken(token.IDENTIFIER) {
+			p.expectError(token.IDENTIFIER)
+			return nil
+		}
+		name.Alias = p.curToken.Literal
+
+		is.With = append(is.With, name)
+
+		if !p.expectNextToken(token.OP_COMMA) {
+			break
+		}
+	}
+
+	if !p.expectNextToken(token.OP_BRACKET_CURLY_RIGHT) {
+		p.expectError(token.OP_BRACKET_CURLY_RIGHT)
+		return nil
+	}
+
+	is.Span = p.spanFrom(is.Token)
+
+	return is
+}
@@ -0,0 +1,103 @@
+package resolver
+
+import "github.com/jordan-rash/go-wit/ast"
+
+// keep returns the items whose gates are enabled, reusing the backing
+// array of items.
+func keep[T any](r *resolver, items []T, gates func(T) ast.Gates) []T {
+	out := items[:0]
+	for _, item := range items {
+		if gates(item).Enabled(r.features) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func useGates(u *ast.Use) ast.Gates { return u.Gates }
+
+func interfaceGates(i *ast.Interface) ast.Gates { return i.Gates }
+
+func worldGates(w *ast.World) ast.Gates { return w.Gates }
+
+func funcGates(f *ast.FuncShape) ast.Gates { return f.Gates }
+
+// filterPackages removes the disabled items of the nested packages pkgs.
+func (r *resolver) filterPackages(pkgs []*ast.Package) {
+	for _, pkg := range pkgs {
+		pkg.Interfaces = r.filterInterfaces(pkg.Interfaces)
+		pkg.Worlds = r.filterWorlds(pkg.Worlds)
+	}
+}
+
+// filterInterfaces removes disabled interfaces and the disabled items of
+// those that remain.
+func (r *resolver) filterInterfaces(interfaces []*ast.Interface) []*ast.Interface {
+	interfaces = keep(r, interfaces, interfaceGates)
+
+	for _, i := range interfaces {
+		r.filterInterfaceItems(&i.Items)
+	}
+
+	return interfaces
+}
+
+func (r *resolver) filterInterfaceItems(ii *ast.InterfaceItems) {
+	ii.UseItems = keep(r, ii.UseItems, func(u *ast.UseShape) ast.Gates { return u.Gates })
+	ii.FuncItems = keep(r, ii.FuncItems, funcGates)
+	ii.TypedefItems = r.filterTypeDefs(ii.TypedefItems)
+}
+
+// filterWorlds removes disabled worlds and the disabled items of those that
+// remain.
+func (r *resolver) filterWorlds(worlds []*ast.World) []*ast.World {
+	worlds = keep(r, worlds, worldGates)
+
+	for _, w := range worlds {
+		w.ImportItems = keep(r, w.ImportItems, func(i *ast.ImportShape) ast.Gates { return i.Gates })
+		w.ExportItems = keep(r, w.ExportItems, func(e *ast.ExportShape) ast.Gates { return e.Gates })
+		w.UseItems = keep(r, w.UseItems, func(u *ast.UseShape) ast.Gates { return u.Gates })
+		w.IncludeItems = keep(r, w.IncludeItems, func(i *ast.IncludeShape) ast.Gates { return i.Gates })
+		w.TypedefItems = r.filterTypeDefs(w.TypedefItems)
+
+		for _, i := range w.ImportItems {
+			if i.Interface != nil {
+				r.filterInterfaceItems(i.Interface)
+			}
+		}
+		for _, e := range w.ExportItems {
+			if e.Interface != nil {
+				r.filterInterfaceItems(e.Interface)
+			}
+		}
+	}
+
+	return worlds
+}
+
+// filterTypeDefs removes disabled type definitions and the disabled fields
+// and cases of those that remain.
+func (r *resolver) filterTypeDefs(typedefs []*ast.TypeDef) []*ast.TypeDef {
+	typedefs = keep(r, typedefs, func(td *ast.TypeDef) ast.Gates { return td.Gates })
+
+	for _, td := range typedefs {
+		switch v := td.Kind.(type) {
+		case *ast.RecordShape:
+			v.Fields = keep(r, v.Fields, func(f *ast.RecordField) ast.Gates { return f.Gates })
+		case *ast.ResourceShape:
+			if v.Constructor != nil && !v.Constructor.Gates.Enabled(r.features) {
+				v.Constructor = nil
+			}
+			v.Methods = keep(r, v.Methods, funcGates)
+			v.StaticFuncs = keep(r, v.StaticFuncs, funcGates)
+		case *ast.VariantShape:
+			v.Cases = keep(r, v.Cases, func(c *ast.VariantCase) ast.Gates { return c.Gates })
+		case *ast.EnumShape:
+			v.Cases = keep(r, v.Cases, func(c *ast.EnumCase) ast.Gates { return c.Gates })
+		case *ast.FlagShape:
+			v.Flags = keep(r, v.Flags, func(f *ast.Flag) ast.Gates { return f.Gates })
+		}
+	}
+
+	return typedefs
+}
@@ -0,0 +1,342 @@
+// Package resolver resolves the names used by types in a parsed WIT tree.
+package resolver
+
+import (
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/diagnostic"
+	"github.com/jordan-rash/go-wit/token"
+)
+
+// Diagnostic codes reported by the resolver
+const (
+	ERROR_NOT_A_RESOURCE = "R0001"
+)
+
+type resolver struct {
+	interfaces map[string]*ast.Interface
+	scopes     map[*ast.InterfaceItems]scope
+	errors     diagnostic.DiagnosticList
+
+	// enabled features, nil when items aren't filtered by feature
+	features map[string]bool
+}
+
+// Option configures Resolve and ResolvePackage.
+type Option func(*resolver)
+
+// WithFeatures removes every item gated behind an `@unstable` feature that
+// isn't one of features from the tree before resolving it. Without this
+// option all items are kept.
+func WithFeatures(features ...string) Option {
+	return func(r *resolver) {
+		r.features = make(map[string]bool, len(features))
+		for _, f := range features {
+			r.features[f] = true
+		}
+	}
+}
+
+// Resolve resolves the type names used in tree. Resources referred to by
+// their bare name are owned handles, so those references are rewritten into
+// implicit `own` handles. Names that can't be found locally, such as types
+// used from other packages, are left untouched.
+func Resolve(tree *ast.AST, opts ...Option) diagnostic.DiagnosticList {
+	if r := newResolver(opts); r.features != nil {
+		tree.Uses = keep(r, tree.Uses, useGates)
+		tree.Interfaces = r.filterInterfaces(tree.Interfaces)
+		tree.Worlds = r.filterWorlds(tree.Worlds)
+		r.filterPackages(tree.Packages)
+	}
+
+	return resolvePackages(resolve(tree.Interfaces, tree.Worlds), tree.Packages)
+}
+
+// ResolvePackage resolves the type names used in all files of pkg as
+// Resolve does. Interfaces may be used from any file of the package, and
+// from any block of a nested package.
+func ResolvePackage(pkg *ast.PackageSet, opts ...Option) diagnostic.DiagnosticList {
+	if r := newResolver(opts); r.features != nil {
+		pkg.Uses = keep(r, pkg.Uses, useGates)
+		pkg.Interfaces = r.filterInterfaces(pkg.Interfaces)
+		pkg.Worlds = r.filterWorlds(pkg.Worlds)
+		r.filterPackages(pkg.Packages)
+
+		// the files share their items with pkg, only their own lists are left
+		for _, f := range pkg.Files {
+			f.Uses = keep(r, f.Uses, useGates)
+			f.Interfaces = keep(r, f.Interfaces, interfaceGates)
+			f.Worlds = keep(r, f.Worlds, worldGates)
+
+			for _, block := range f.Packages {
+				block.Interfaces = keep(r, block.Interfaces, interfaceGates)
+				block.Worlds = keep(r, block.Worlds, worldGates)
+			}
+		}
+	}
+
+	return resolvePackages(resolve(pkg.Interfaces, pkg.Worlds), pkg.Packages)
+}
+
+func newResolver(opts []Option) *resolver {
+	r := &resolver{
+		interfaces: make(map[string]*ast.Interface),
+		scopes:     make(map[*ast.InterfaceItems]scope),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// resolvePackages resolves the nested packages pkgs, each in a namespace of
+// its own, and returns their diagnostics appended to errs.
+func resolvePackages(errs diagnostic.DiagnosticList, pkgs []*ast.Package) diagnostic.DiagnosticList {
+	for _, pkg := range pkgs {
+		errs = append(errs, resolve(pkg.Interfaces, pkg.Worlds)...)
+	}
+	return errs
+}
+
+func resolve(interfaces []*ast.Interface, worlds []*ast.World) diagnostic.DiagnosticList {
+	r := newResolver(nil)
+
+	for _, i := range interfaces {
+		r.interfaces[i.Name] = i
+	}
+
+	for _, i := range interfaces {
+		r.resolveInterfaceItems(&i.Items)
+	}
+
+	for _, w := range worlds {
+		r.resolveWorld(w)
+	}
+
+	return r.errors
+}
+
+// scope maps the type names visible in an interface or world to their
+// definitions.
+type scope map[string]binding
+
+// binding is a type definition visible in a scope. For definitions brought
+// in by a use, from holds the items of the interface declaring them, whose
+// scope the names in the definition are looked up in.
+type binding struct {
+	def  *ast.TypeDef
+	from *ast.InterfaceItems
+}
+
+// scopeOf returns the scope of the interface items ii, creating it on first
+// use.
+func (r *resolver) scopeOf(ii *ast.InterfaceItems) scope {
+	s, ok := r.scopes[ii]
+	if !ok {
+		s = r.newScope(ii.UseItems, ii.TypedefItems)
+		r.scopes[ii] = s
+	}
+	return s
+}
+
+func (r *resolver) newScope(uses []*ast.UseShape, typedefs []*ast.TypeDef) scope {
+	s := make(scope)
+
+	for _, u := range uses {
+		path := u.UseInterface.Path
+		if path.IsQualified() {
+			continue
+		}
+
+		iFace, ok := r.interfaces[path.Name.Value]
+		if !ok {
+			continue
+		}
+
+		for _, item := range u.UseInterface.Items {
+			name := item.Value
+			if item.Alias != "" {
+				name = item.Alias
+			}
+
+			for _, td := range iFace.Items.TypedefItems {
+				if td.Name != nil && td.Name.Value == item.Value {
+					s[name] = binding{def: td, from: &iFace.Items}
+				}
+			}
+		}
+	}
+
+	for _, td := range typedefs {
+		if td.Name != nil {
+			s[td.Name.Value] = binding{def: td}
+		}
+	}
+
+	return s
+}
+
+// isResource reports whether name refers to a resource in scope s,
+// following type aliases in the scope of the interface declaring them. The
+// second result is false when a name on the way can't be found.
+func (r *resolver) isResource(s scope, name string) (bool, bool) {
+	seen := make(map[*ast.TypeDef]bool)
+
+	for {
+		b, ok := s[name]
+		if !ok {
+			return false, false
+		}
+		if seen[b.def] {
+			return false, true
+		}
+		seen[b.def] = true
+
+		switch v := b.def.Kind.(type) {
+		case *ast.ResourceShape:
+			return true, true
+		case *ast.TypeShape:
+			named, ok := v.Value.(*ast.Named)
+			if !ok {
+				return false, true
+			}
+			name = named.Name.Value
+			if b.from != nil {
+				s = r.scopeOf(b.from)
+			}
+		default:
+			return false, true
+		}
+	}
+}
+
+func (r *resolver) resolveWorld(w *ast.World) {
+	s := r.newScope(w.UseItems, w.TypedefItems)
+
+	for _, td := range w.TypedefItems {
+		r.resolveTypeDef(s, td)
+	}
+
+	for _, i := range w.ImportItems {
+		r.resolveExtern(s, i.Func, i.Interface)
+	}
+
+	for _, e := range w.ExportItems {
+		r.resolveExtern(s, e.Func, e.Interface)
+	}
+}
+
+func (r *resolver) resolveExtern(s scope, ft *ast.FuncType, ii *ast.InterfaceItems) {
+	if ft != nil {
+		r.resolveFuncType(s, ft)
+	}
+	if ii != nil {
+		r.resolveInterfaceItems(ii)
+	}
+}
+
+func (r *resolver) resolveInterfaceItems(ii *ast.InterfaceItems) {
+	s := r.scopeOf(ii)
+
+	for _, td := range ii.TypedefItems {
+		r.resolveTypeDef(s, td)
+	}
+
+	for _, f := range ii.FuncItems {
+		r.resolveFunc(s, f)
+	}
+}
+
+func (r *resolver) resolveTypeDef(s scope, td *ast.TypeDef) {
+	switch v := td.Kind.(type) {
+	case *ast.TypeShape:
+		// `type a = r` aliases the resource itself rather than a handle to it
+		if _, ok := v.Value.(*ast.Named); !ok {
+			r.resolveType(s, &v.Value)
+		}
+	case *ast.RecordShape:
+		for _, f := range v.Fields {
+			r.resolveType(s, &f.Type)
+		}
+	case *ast.VariantShape:
+		for _, c := range v.Cases {
+			r.resolveType(s, &c.Type)
+		}
+	case *ast.UnionShape:
+		for i := range v.Cases {
+			r.resolveType(s, &v.Cases[i])
+		}
+	case *ast.ResourceShape:
+		if v.Constructor != nil {
+			r.resolveFunc(s, v.Constructor)
+		}
+		for _, f := range v.Methods {
+			r.resolveFunc(s, f)
+		}
+		for _, f := range v.StaticFuncs {
+			r.resolveFunc(s, f)
+		}
+	}
+}
+
+func (r *resolver) resolveFunc(s scope, f *ast.FuncShape) {
+	if f.Func != nil {
+		r.resolveFuncType(s, f.Func)
+	}
+}
+
+func (r *resolver) resolveFuncType(s scope, ft *ast.FuncType) {
+	if ft.ParamList != nil {
+		r.resolveNamedTypes(s, *ft.ParamList)
+	}
+	if ft.ResultList != nil {
+		r.resolveNamedTypes(s, *ft.ResultList)
+	}
+}
+
+func (r *resolver) resolveNamedTypes(s scope, l []*ast.NamedType) {
+	for _, nt := range l {
+		if nt != nil {
+			r.resolveType(s, &nt.Type)
+		}
+	}
+}
+
+// resolveType resolves the type *ty, replacing references to resources by
+// implicit owned handles.
+func (r *resolver) resolveType(s scope, ty *ast.Type) {
+	switch v := (*ty).(type) {
+	case *ast.Named:
+		if ok, _ := r.isResource(s, v.Name.Value); ok {
+			*ty = &ast.Handle{
+				Token:    v.Name.Token,
+				Kind:     token.KEYWORD_OWN,
+				Resource: v.Name,
+				Implicit: true,
+				Span:     v.Name.Token.Span(),
+			}
+		}
+	case *ast.List:
+		r.resolveType(s, &v.Elem)
+	case *ast.Option:
+		r.resolveType(s, &v.Elem)
+	case *ast.Result:
+		r.resolveType(s, &v.Ok)
+		r.resolveType(s, &v.Err)
+	case *ast.Tuple:
+		for i := range v.Elems {
+			r.resolveType(s, &v.Elems[i])
+		}
+	case *ast.Future:
+		r.resolveType(s, &v.Elem)
+	case *ast.Stream:
+		r.resolveType(s, &v.Elem)
+	case *ast.Handle:
+		r.resolveHandle(s, v)
+	}
+}
+
+func (r *resolver) resolveHandle(s scope, h *ast.Handle) {
+	if ok, found := r.isResource(s, h.Resource.Value); found && !ok {
+		r.errors.Addf(h.Resource.Token.Span(), ERROR_NOT_A_RESOURCE, "%s<%s>: %s is not a resource", h.Token.Literal, h.Resource.Value, h.Resource.Value)
+	}
+}
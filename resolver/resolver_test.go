@@ -0,0 +1,259 @@
+package resolver_test
+
+import (
+	"testing"
+
+	"github.com/jordan-rash/go-wit/ast"
+	"github.com/jordan-rash/go-wit/lexer"
+	"github.com/jordan-rash/go-wit/parser"
+	"github.com/jordan-rash/go-wit/resolver"
+	"github.com/jordan-rash/go-wit/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func parse(t *testing.T, input string) *ast.AST {
+	t.Helper()
+
+	p := parser.New(lexer.NewLexer(input))
+	tree := p.Parse()
+	assert.NoError(t, p.Errors().Err())
+
+	return tree
+}
+
+func handle(t *testing.T, ty ast.Type) *ast.Handle {
+	t.Helper()
+
+	hs, _ := ty.(*ast.Handle)
+	return hs
+}
+
+func TestImplicitOwn(t *testing.T) {
+	tree := parse(t, `interface streams {
+  resource input-stream {}
+  type stream-alias = input-stream
+}
+
+interface files {
+  use streams.{input-stream, stream-alias as alias}
+
+  record handle {
+    id: u32,
+  }
+
+  open: func(name: string) -> input-stream
+  close: func(s: alias, h: handle)
+  peek: func(s: borrow<input-stream>)
+  pending: func() -> future<input-stream>
+}`)
+
+	assert.NoError(t, resolver.Resolve(tree).Err())
+
+	files := tree.Interfaces[1]
+	funcs := files.Items.FuncItems
+
+	open := funcs[0].Func
+	hs := handle(t, (*open.ResultList)[0].Type)
+	if assert.NotNil(t, hs) {
+		assert.Equal(t, token.KEYWORD_OWN, string(hs.Kind))
+		assert.Equal(t, "input-stream", hs.Resource.Value)
+		assert.True(t, hs.Implicit)
+	}
+
+	closeParams := *funcs[1].Func.ParamList
+	hs = handle(t, closeParams[0].Type)
+	if assert.NotNil(t, hs) {
+		assert.Equal(t, "alias", hs.Resource.Value)
+		assert.True(t, hs.Implicit)
+	}
+	assert.Nil(t, handle(t, closeParams[1].Type))
+
+	hs = handle(t, (*funcs[2].Func.ParamList)[0].Type)
+	if assert.NotNil(t, hs) {
+		assert.Equal(t, token.KEYWORD_BORROW, string(hs.Kind))
+		assert.False(t, hs.Implicit)
+	}
+
+	fs := (*funcs[3].Func.ResultList)[0].Type.(*ast.Future)
+	hs = handle(t, fs.Elem)
+	if assert.NotNil(t, hs) {
+		assert.True(t, hs.Implicit)
+	}
+}
+
+func TestAliasScope(t *testing.T) {
+	tree := parse(t, `interface streams {
+  resource input-stream {}
+  type reader = input-stream
+  type bytes = list<u8>
+  type data = bytes
+}
+
+interface files {
+  use streams.{reader, data}
+
+  record input-stream {
+    id: u32,
+  }
+  resource bytes {}
+
+  open: func() -> reader
+  read: func() -> data
+}`)
+
+	assert.NoError(t, resolver.Resolve(tree).Err())
+
+	// aliases are followed in the interface declaring them, not the one
+	// using them
+	funcs := tree.Interfaces[1].Items.FuncItems
+	hs := handle(t, (*funcs[0].Func.ResultList)[0].Type)
+	if assert.NotNil(t, hs) {
+		assert.Equal(t, "reader", hs.Resource.Value)
+		assert.True(t, hs.Implicit)
+	}
+	assert.Nil(t, handle(t, (*funcs[1].Func.ResultList)[0].Type))
+}
+
+func TestHandleOfNonResource(t *testing.T) {
+	tree := parse(t, `interface files {
+  record handle {
+    id: u32,
+  }
+
+  close: func(h: borrow<handle>, u: own<unknown>)
+}`)
+
+	errs := resolver.Resolve(tree)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, resolver.ERROR_NOT_A_RESOURCE, errs[0].Code)
+		assert.Equal(t, 6, errs[0].Span.Start.Line)
+	}
+}
+
+func TestResolvePackage(t *testing.T) {
+	pkg, err := parser.MergeFiles(
+		parse(t, "interface streams {\n  resource input-stream {}\n}"),
+		parse(t, "interface files {\n  use streams.{input-stream}\n  open: func() -> input-stream\n}"),
+	)
+	assert.NoError(t, err)
+	assert.Empty(t, resolver.ResolvePackage(pkg))
+
+	files := pkg.Interface("files")
+	if assert.NotNil(t, files) && assert.Len(t, files.Items.FuncItems, 1) {
+		ft := files.Items.FuncItems[0].Func
+		if hs := handle(t, (*ft.ResultList)[0].Type); assert.NotNil(t, hs) {
+			assert.True(t, hs.Implicit)
+		}
+	}
+}
+
+func TestResolvePackageNested(t *testing.T) {
+	pkg, err := parser.MergeFiles(
+		parse(t, "package foo:dep {\n  interface streams {\n    resource input-stream {}\n  }\n}"),
+		parse(t, `package foo:dep {
+  interface files {
+    use streams.{input-stream}
+    open: func() -> input-stream
+    @unstable(feature = fancy)
+    close: func(s: input-stream)
+  }
+
+  @unstable(feature = fancy)
+  world fancy {}
+}`),
+	)
+	assert.NoError(t, err)
+	assert.Empty(t, resolver.ResolvePackage(pkg, resolver.WithFeatures()))
+
+	if !assert.Len(t, pkg.Packages, 1) {
+		return
+	}
+	dep := pkg.Packages[0]
+	assert.Empty(t, dep.Worlds)
+	assert.Empty(t, pkg.Files[1].Packages[0].Worlds)
+
+	if assert.Len(t, dep.Interfaces, 2) && assert.Len(t, dep.Interfaces[1].Items.FuncItems, 1) {
+		ft := dep.Interfaces[1].Items.FuncItems[0].Func
+		if hs := handle(t, (*ft.ResultList)[0].Type); assert.NotNil(t, hs) {
+			assert.True(t, hs.Implicit)
+		}
+	}
+}
+
+func TestWithFeatures(t *testing.T) {
+	input := `interface clocks {
+  now: func() -> u64
+  @unstable(feature = fancy)
+  later: func() -> u64
+
+  record instant {
+    seconds: u64,
+    @unstable(feature = nanos)
+    nanos: u32,
+  }
+
+  resource timer {
+    @unstable(feature = fancy)
+    constructor()
+    @unstable(feature = fancy)
+    reset: func()
+  }
+
+  enum unit {
+    seconds,
+    @unstable(feature = nanos)
+    nanos,
+  }
+
+  flags clock {
+    wall,
+    @unstable(feature = fancy)
+    monotonic,
+  }
+}
+
+@unstable(feature = fancy)
+interface fancy {}
+
+world imports {
+  import clocks
+  @unstable(feature = fancy)
+  import fancy
+}
+`
+
+	tree := parse(t, input)
+	assert.Empty(t, resolver.Resolve(tree))
+	assert.Len(t, tree.Interfaces, 2)
+
+	tree = parse(t, input)
+	assert.Empty(t, resolver.Resolve(tree, resolver.WithFeatures("nanos")))
+
+	if assert.Len(t, tree.Interfaces, 1) {
+		clocks := tree.Interfaces[0]
+		if assert.Len(t, clocks.Items.FuncItems, 1) {
+			assert.Equal(t, "now", clocks.Items.FuncItems[0].Name.Value)
+		}
+		rs := clocks.Items.TypedefItems[0].Kind.(*ast.RecordShape)
+		assert.Len(t, rs.Fields, 2)
+		timer := clocks.Items.TypedefItems[1].Kind.(*ast.ResourceShape)
+		assert.Nil(t, timer.Constructor)
+		assert.Empty(t, timer.Methods)
+		unit := clocks.Items.TypedefItems[2].Kind.(*ast.EnumShape)
+		assert.Len(t, unit.Cases, 2)
+		clock := clocks.Items.TypedefItems[3].Kind.(*ast.FlagShape)
+		if assert.Len(t, clock.Flags, 1) {
+			assert.Equal(t, "wall", clock.Flags[0].Identifier.Value)
+		}
+	}
+	if w := tree.World("imports"); assert.NotNil(t, w) {
+		assert.Len(t, w.ImportItems, 1)
+	}
+
+	tree = parse(t, input)
+	resolver.Resolve(tree, resolver.WithFeatures())
+	rs := tree.Interfaces[0].Items.TypedefItems[0].Kind.(*ast.RecordShape)
+	assert.Len(t, rs.Fields, 1)
+	unit := tree.Interfaces[0].Items.TypedefItems[2].Kind.(*ast.EnumShape)
+	assert.Len(t, unit.Cases, 1)
+}
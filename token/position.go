@@ -0,0 +1,49 @@
+package token
+
+import "fmt"
+
+// Position describes a location in a source file. Line and Column start at 1,
+// Offset is the byte offset from the start of the input. Column counts bytes
+// while UTF16Column counts UTF-16 code units, as the language server
+// protocol expects.
+type Position struct {
+	Filename    string
+	Offset      int
+	Line        int
+	Column      int
+	UTF16Column int
+}
+
+// IsValid reports whether the position has been set.
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// String returns the position in one of the following forms:
+//
+//	file:line:column    valid position with file name
+//	line:column         valid position without file name
+//	file                invalid position with file name
+//	-                   invalid position without file name
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
+}
+
+// Span is the half open range [Start, End) covered by a token or node.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// IsValid reports whether the span has a valid start position.
+func (s Span) IsValid() bool { return s.Start.IsValid() }
+
+func (s Span) String() string { return s.Start.String() }
@@ -5,13 +5,24 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+
+	// Explicit is set for identifiers written with a leading `%`, which
+	// allows keywords to be used as names. The `%` is not part of Literal.
+	Explicit bool
+
+	Pos Position // position of the first character of the token
+	End Position // position immediately after the token
 }
 
+// Span returns the source range covered by the token.
+func (t Token) Span() Span { return Span{Start: t.Pos, End: t.End} }
+
 const (
 	ILLEGAL     = "ILLEGAL"
 	END_OF_FILE = "EOF"
 	IDENTIFIER  = "IDENT"
 	INT         = "INT"
+	VERSION     = "VERSION" // semver such as 0.2.0-rc.1+build.5
 
 	// block comments
 	COMMENT_BLOCK_START   = "/*"